@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/answer"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/diagnosis"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/generator"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/library"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/report"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	gin "github.com/gin-gonic/gin"
+)
+
+// maxRequestBytes is APIが受け付けるリクエストボディの上限
+const maxRequestBytes = 1 << 20
+
+// apiError is APIのエラーレスポンス
+type apiError struct {
+	Error  string                       `json:"error"`
+	Fields totalcosting.ValidationError `json:"fields,omitempty"` // 入力項目ごとの検証エラー
+}
+
+// validateResponse is validateのレスポンス
+type validateResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// addAPI is バージョンごとのAPIのルーティングを加える
+// storeがnilなら問題集のAPIは加えない
+func addAPI(router *gin.Engine, store *library.Store) {
+	v1 := router.Group("/api/v1")
+	v1.Use(limitBody(maxRequestBytes))
+	v1.POST("/totalcosting/solve", solveBox)
+	v1.POST("/totalcosting/validate", validateBox)
+	v1.POST("/totalcosting/check", checkBox)
+	v1.POST("/totalcosting/diagnose", diagnoseBox)
+	v1.POST("/totalcosting/generate", generateBox)
+	v1.POST("/totalcosting/statement", statementBox)
+
+	if store != nil {
+		h := problemHandler{store}
+		v1.GET("/problems", h.list)
+		v1.POST("/problems", h.create)
+		v1.GET("/problems/:id", h.get)
+		v1.PUT("/problems/:id", h.update)
+		v1.DELETE("/problems/:id", h.delete)
+		v1.POST("/problems/:id/check", h.check)
+		v1.POST("/problems/:id/diagnose", h.diagnose)
+		v1.GET("/problems/:id/statement", h.statement)
+	}
+}
+
+// limitBody is リクエストボディをnバイトまでに制限する
+func limitBody(n int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.ContentLength > n {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, apiError{Error: "request body too large"})
+			return
+		}
+
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, n)
+		ctx.Next()
+	}
+}
+
+// bodyTooLarge is http.MaxBytesReaderが上限を超えたときに返すエラーのメッセージ
+// Go 1.16には専用のエラー型がないのでメッセージで判定する
+const bodyTooLarge = "http: request body too large"
+
+// readBody is リクエストボディを読み込む
+// 失敗したときはエラーレスポンスを書いてfalseを返す
+// 上限を超えたときは413, それ以外の読み込みエラーは400
+func readBody(ctx *gin.Context) ([]byte, bool) {
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		if err.Error() == bodyTooLarge {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, apiError{Error: "request body too large"})
+		} else {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: "failed to read request body"})
+		}
+		return nil, false
+	}
+
+	return body, true
+}
+
+// abortWithError is エラーの種類に合わせたステータスでエラーレスポンスを書く
+// 検証エラーは422, 問題がなければ404, それ以外は500
+func abortWithError(ctx *gin.Context, err error) {
+	switch e := err.(type) {
+	case totalcosting.ValidationError:
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: e})
+	default:
+		if err == library.ErrNotFound {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, apiError{Error: err.Error()})
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, apiError{Error: err.Error()})
+	}
+}
+
+// bindBox is リクエストボディのJSONからBoxを読み込んで検証する
+// 失敗したときはエラーレスポンスを書いてfalseを返す
+func bindBox(ctx *gin.Context) (totalcosting.Box, bool) {
+	body, ok := readBody(ctx)
+	if !ok {
+		return totalcosting.Box{}, false
+	}
+
+	box, err := totalcosting.DecodeBox(bytes.NewReader(body))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: err.Error()})
+		return totalcosting.Box{}, false
+	}
+
+	if err := box.Validate(); err != nil {
+		abortWithError(ctx, err)
+		return totalcosting.Box{}, false
+	}
+
+	return box, true
+}
+
+// solveBox is 問題を解いて計算結果と計算過程を含むBoxを返す
+func solveBox(ctx *gin.Context) {
+	box, ok := bindBox(ctx)
+	if !ok {
+		return
+	}
+
+	box.Run()
+
+	var buf bytes.Buffer
+	if err := totalcosting.EncodeBox(&buf, box); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", buf.Bytes())
+}
+
+// validateBox is 問題を検証だけする
+func validateBox(ctx *gin.Context) {
+	if _, ok := bindBox(ctx); !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, validateResponse{Valid: true})
+}
+
+// checkRequest is 採点のリクエスト
+type checkRequest struct {
+	Box       *totalcosting.Box `json:"box,omitempty"` // 問題集の問題を採点するときは省略する
+	Answer    answer.Answer     `json:"answer"`
+	Tolerance *float64          `json:"tolerance,omitempty"` // 省略したらanswer.DefaultTolerance
+}
+
+// bindCheck is リクエストボディのJSONから採点のリクエストを読み込む
+// 失敗したときはエラーレスポンスを書いてfalseを返す
+func bindCheck(ctx *gin.Context) (checkRequest, bool) {
+	body, ok := readBody(ctx)
+	if !ok {
+		return checkRequest{}, false
+	}
+
+	var req checkRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: "decode check request: " + err.Error()})
+		return checkRequest{}, false
+	}
+
+	return req, true
+}
+
+// checkAnswer is 問題を解いて解答を採点した結果を返す
+// diagnoseなら誤りの診断も返す
+func checkAnswer(ctx *gin.Context, box totalcosting.Box, req checkRequest, diagnose bool) {
+	if err := box.Validate(); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	tolerance := answer.DefaultTolerance
+	if req.Tolerance != nil {
+		tolerance = *req.Tolerance
+	}
+
+	var result interface{}
+	var err error
+	if diagnose {
+		result, err = diagnosis.Diagnose(box, req.Answer, tolerance)
+	} else {
+		box.Run()
+		result, err = answer.Check(box, req.Answer, tolerance)
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// boxCheckHandler is リクエストの問題で解答を採点するハンドラーを返す
+func boxCheckHandler(diagnose bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req, ok := bindCheck(ctx)
+		if !ok {
+			return
+		}
+		if req.Box == nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: "box is required"})
+			return
+		}
+
+		checkAnswer(ctx, *req.Box, req, diagnose)
+	}
+}
+
+// checkBox is リクエストの問題で解答を採点する
+var checkBox = boxCheckHandler(false)
+
+// diagnoseBox is リクエストの問題で解答を採点し, 誤りを診断する
+var diagnoseBox = boxCheckHandler(true)
+
+// generateResponse is generateのレスポンス
+type generateResponse struct {
+	Options generator.Options `json:"options"` // 省略した項目を既定値で埋めた論点
+	Box     totalcosting.Box  `json:"box"`
+}
+
+// generateBox is リクエストの論点で問題を生成する
+// 省略した項目はgenerator.DefaultOptions, シードを省略したら時刻から決める
+func generateBox(ctx *gin.Context) {
+	body, ok := readBody(ctx)
+	if !ok {
+		return
+	}
+
+	o := generator.DefaultOptions(time.Now().UnixNano())
+	if len(bytes.TrimSpace(body)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&o); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: "decode options: " + err.Error()})
+			return
+		}
+	}
+	if err := o.Validate(); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+
+	box, err := generator.Generate(o)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, generateResponse{Options: o, Box: box})
+}
+
+// statementResponse is statementのレスポンス
+type statementResponse struct {
+	Statement string `json:"statement"`
+}
+
+// queryLang is クエリのlang(ja, en)から問題文の言語を返す
+// 失敗したときはエラーレスポンスを書いてfalseを返す
+func queryLang(ctx *gin.Context) (report.Lang, bool) {
+	l, err := report.ParseLang(ctx.Query("lang"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: err.Error()})
+		return l, false
+	}
+
+	return l, true
+}
+
+// statementBox is リクエストの問題から問題文を作る
+func statementBox(ctx *gin.Context) {
+	l, ok := queryLang(ctx)
+	if !ok {
+		return
+	}
+
+	box, ok := bindBox(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, statementResponse{Statement: report.Statement(box, l)})
+}
+
+// problemHandler is 問題集のAPI
+type problemHandler struct {
+	store *library.Store
+}
+
+// list is 問題の一覧を返す
+// tag(複数指定可), q, offset, limitで絞り込む
+func (h problemHandler) list(ctx *gin.Context) {
+	q := library.Query{
+		Tags: ctx.QueryArray("tag"),
+		Text: ctx.Query("q"),
+	}
+
+	for name, v := range map[string]*int{"offset": &q.Offset, "limit": &q.Limit} {
+		s := ctx.Query(name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: name + " must be a non-negative integer"})
+			return
+		}
+		*v = n
+	}
+
+	page, err := h.store.List(q)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// get is IDの問題を返す
+func (h problemHandler) get(ctx *gin.Context) {
+	p, err := h.store.Get(ctx.Param("id"))
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p)
+}
+
+// create is 問題を追加する
+func (h problemHandler) create(ctx *gin.Context) {
+	p, ok := bindProblem(ctx)
+	if !ok {
+		return
+	}
+
+	p, err := h.store.Create(p)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", "/api/v1/problems/"+p.ID)
+	ctx.JSON(http.StatusCreated, p)
+}
+
+// update is IDの問題を書き換える
+func (h problemHandler) update(ctx *gin.Context) {
+	p, ok := bindProblem(ctx)
+	if !ok {
+		return
+	}
+
+	p, err := h.store.Update(ctx.Param("id"), p)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, p)
+}
+
+// delete is IDの問題を削除する
+func (h problemHandler) delete(ctx *gin.Context) {
+	if err := h.store.Delete(ctx.Param("id")); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// check is IDの問題で解答を採点する
+func (h problemHandler) check(ctx *gin.Context) {
+	h.grade(ctx, false)
+}
+
+// diagnose is IDの問題で解答を採点し, 誤りを診断する
+func (h problemHandler) diagnose(ctx *gin.Context) {
+	h.grade(ctx, true)
+}
+
+// statement is IDの問題のBoxから問題文を作る
+func (h problemHandler) statement(ctx *gin.Context) {
+	l, ok := queryLang(ctx)
+	if !ok {
+		return
+	}
+
+	p, err := h.store.Get(ctx.Param("id"))
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, statementResponse{Statement: report.Statement(p.Box, l)})
+}
+
+// grade is IDの問題で解答を採点する
+func (h problemHandler) grade(ctx *gin.Context, diagnose bool) {
+	req, ok := bindCheck(ctx)
+	if !ok {
+		return
+	}
+	if req.Box != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: "box must not be given"})
+		return
+	}
+
+	p, err := h.store.Get(ctx.Param("id"))
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	checkAnswer(ctx, p.Box, req, diagnose)
+}
+
+// bindProblem is リクエストボディのJSONから問題を読み込む
+// 失敗したときはエラーレスポンスを書いてfalseを返す
+func bindProblem(ctx *gin.Context) (library.Problem, bool) {
+	body, ok := readBody(ctx)
+	if !ok {
+		return library.Problem{}, false
+	}
+
+	var p library.Problem
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, apiError{Error: "decode problem: " + err.Error()})
+		return library.Problem{}, false
+	}
+
+	return p, true
+}
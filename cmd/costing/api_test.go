@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/answer"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/diagnosis"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/generator"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/library"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiBody is APIのテストに使う問題
+const apiBody = `{
+  "master": [
+    {"type": "First", "unit": 300, "progress": 0.6},
+    {"type": "Input", "unit": 1380},
+    {"type": "Output", "unit": 1440},
+    {"type": "Last", "unit": 240, "progress": 0.3}
+  ],
+  "costs": [
+    {"input_on_avg": true, "calculation_method": "AVG", "defective_product_method": "NonNeglecting",
+     "first_cost": 161640, "input_cost": 972360}
+  ]
+}`
+
+func TestSolveBox(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/totalcosting/solve", strings.NewReader(apiBody))
+	newRouter(nil).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	box, err := totalcosting.DecodeBox(w.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 1080000.0, box.ProductTotalCost)
+	assert.Equal(t, 54000.0, box.EOTMTotalCost)
+	assert.NotEmpty(t, box.Trace)
+}
+
+func TestAPIError(t *testing.T) {
+	testCases := []struct {
+		Path   string
+		Body   string
+		Code   int
+		Fields int
+	}{
+		{"/api/v1/totalcosting/solve", `{"master": [}`, http.StatusBadRequest, 0},
+		{"/api/v1/totalcosting/solve", `{"unknown": 1}`, http.StatusBadRequest, 0},
+		{"/api/v1/totalcosting/solve", `{"master": [], "costs": []}`, http.StatusUnprocessableEntity, 2},
+		{"/api/v1/totalcosting/validate", `{"master": [], "costs": []}`, http.StatusUnprocessableEntity, 2},
+		{"/api/v1/totalcosting/solve", `{"master": [{"type": "Input", "unit": 0}, {"type": "Output", "unit": 0}], "costs": [{"input_on_avg": true}]}`, http.StatusUnprocessableEntity, 1},
+		{"/api/v1/totalcosting/solve", strings.Repeat(" ", maxRequestBytes+1), http.StatusRequestEntityTooLarge, 0},
+	}
+
+	for _, testCase := range testCases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, testCase.Path, strings.NewReader(testCase.Body))
+		newRouter(nil).ServeHTTP(w, req)
+
+		var res apiError
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		if w.Code != testCase.Code || res.Error == "" || len(res.Fields) != testCase.Fields {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidateBox(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/totalcosting/validate", strings.NewReader(apiBody))
+	newRouter(nil).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"valid": true}`, w.Body.String())
+}
+
+func TestLimitBody(t *testing.T) {
+	// Content-Lengthのないリクエストも読み込みで制限する
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/totalcosting/solve", strings.NewReader(apiBody))
+	req.ContentLength = -1
+	router := newRouter(nil)
+	router.POST("/api/v1/limited", limitBody(10), solveBox)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/limited", strings.NewReader(apiBody))
+	req.ContentLength = -1
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestReadBodyError(t *testing.T) {
+	// 上限を超えた以外の読み込みエラーは400
+	for _, path := range []string{"/api/v1/totalcosting/solve", "/diagram.svg"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, iotest.ErrReader(errors.New("connection reset")))
+		req.ContentLength = -1
+		newRouter(nil).ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Invalid result. path:%s, actual:%d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestProblemAPI(t *testing.T) {
+	store, err := library.Open(t.TempDir())
+	assert.NoError(t, err)
+	router := newRouter(store)
+
+	do := func(method string, path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/problems", `{"title": "平均法", "tags": ["工程別"], "box": `+apiBody+`}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "/api/v1/problems/1", w.Header().Get("Location"))
+
+	w = do(http.MethodPut, "/api/v1/problems/1", `{"title": "平均法(改)", "statement": "終点で仕損", "box": `+apiBody+`}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(http.MethodGet, "/api/v1/problems/1", "")
+	var p library.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, "平均法(改)", p.Title)
+	assert.Equal(t, 1440, p.Box.Master[2].Unit)
+
+	w = do(http.MethodGet, "/api/v1/problems?q=終点&limit=5", "")
+	var page library.Page
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, 5, page.Limit)
+
+	w = do(http.MethodDelete, "/api/v1/problems/1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	testCases := []struct {
+		Method string
+		Path   string
+		Body   string
+		Code   int
+	}{
+		{http.MethodGet, "/api/v1/problems/1", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/problems/1", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/problems/1", `{"title": "x", "box": ` + apiBody + `}`, http.StatusNotFound},
+		{http.MethodPost, "/api/v1/problems", `{"title": "x", "box": {"master": [], "costs": []}}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/problems", `{"title": "x", "unknown": 1}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/problems?limit=-1", "", http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		w := do(testCase.Method, testCase.Path, testCase.Body)
+		if w.Code != testCase.Code {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCheckAPI(t *testing.T) {
+	store, err := library.Open(t.TempDir())
+	assert.NoError(t, err)
+	router := newRouter(store)
+
+	do := func(path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/api/v1/problems", `{"title": "平均法", "box": `+apiBody+`}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	answerBody := `"answer": {"product_total_cost": 1080000, "eotm_total_cost": 54000.3}`
+	testCases := []struct {
+		Path    string
+		Body    string
+		Code    int
+		Correct int
+	}{
+		{"/api/v1/totalcosting/check", `{"box": ` + apiBody + `, ` + answerBody + `}`, http.StatusOK, 2},
+		{"/api/v1/totalcosting/check", `{"box": ` + apiBody + `, ` + answerBody + `, "tolerance": 0}`, http.StatusOK, 1},
+		{"/api/v1/problems/1/check", `{` + answerBody + `}`, http.StatusOK, 2},
+		{"/api/v1/totalcosting/check", `{` + answerBody + `}`, http.StatusBadRequest, 0},
+		{"/api/v1/totalcosting/check", `{"box": {"master": [], "costs": []}, ` + answerBody + `}`, http.StatusUnprocessableEntity, 0},
+		{"/api/v1/totalcosting/check", `{"box": ` + apiBody + `, "answer": {"costs": [{"units": {"Unknown": 1}}]}}`, http.StatusBadRequest, 0},
+		{"/api/v1/problems/1/check", `{"box": ` + apiBody + `, ` + answerBody + `}`, http.StatusBadRequest, 0},
+		{"/api/v1/problems/2/check", `{` + answerBody + `}`, http.StatusNotFound, 0},
+	}
+
+	for _, testCase := range testCases {
+		w := do(testCase.Path, testCase.Body)
+
+		var result answer.Result
+		json.Unmarshal(w.Body.Bytes(), &result)
+		if w.Code != testCase.Code || result.Correct != testCase.Correct {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestDiagnoseAPI(t *testing.T) {
+	store, err := library.Open(t.TempDir())
+	assert.NoError(t, err)
+	router := newRouter(store)
+
+	do := func(path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/api/v1/problems", `{"title": "平均法", "box": `+apiBody+`}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// 先入先出法で計算した解答
+	answerBody := `"answer": {"product_total_cost": 1081440, "eotm_total_cost": 52560}`
+	testCases := []struct {
+		Path string
+		Body string
+		Code int
+	}{
+		{"/api/v1/totalcosting/diagnose", `{"box": ` + apiBody + `, ` + answerBody + `}`, http.StatusOK},
+		{"/api/v1/problems/1/diagnose", `{` + answerBody + `}`, http.StatusOK},
+		{"/api/v1/totalcosting/diagnose", `{` + answerBody + `}`, http.StatusBadRequest},
+		{"/api/v1/problems/2/diagnose", `{` + answerBody + `}`, http.StatusNotFound},
+	}
+
+	for _, testCase := range testCases {
+		w := do(testCase.Path, testCase.Body)
+
+		var d diagnosis.Diagnosis
+		json.Unmarshal(w.Body.Bytes(), &d)
+		if w.Code != testCase.Code || (w.Code == http.StatusOK && (len(d.Matches) == 0 || d.Matches[0].Mistake != diagnosis.FIFOInsteadOfAVG)) {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGenerateAPI(t *testing.T) {
+	router := newRouter(nil)
+
+	do := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/totalcosting/generate", strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(`{"seed": 5, "calculation_method": "FIFO", "defect": "both", "difficulty": "hard"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var res generateResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	expected := generator.DefaultOptions(5)
+	expected.CMethod = totalcosting.FIFO
+	expected.Defect = generator.DefectBoth
+	expected.Difficulty = generator.Hard
+	assert.Equal(t, expected, res.Options)
+	assert.NoError(t, res.Box.Validate())
+
+	// 同じシードなら同じ問題
+	again := do(`{"seed": 5, "calculation_method": "FIFO", "defect": "both", "difficulty": "hard"}`)
+	assert.Equal(t, w.Body.String(), again.Body.String())
+
+	// ボディを省略したら既定の論点
+	w = do("")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	testCases := []struct {
+		Body string
+		Code int
+	}{
+		{`{"material": "end"}`, http.StatusBadRequest},
+		{`{"calculation_method": "LIFO"}`, http.StatusBadRequest},
+		{`{"unknown": 1}`, http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		w := do(testCase.Body)
+		if w.Code != testCase.Code {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStatementAPI(t *testing.T) {
+	store, err := library.Open(t.TempDir())
+	assert.NoError(t, err)
+	router := newRouter(store)
+
+	do := func(method string, path string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/problems", `{"title": "平均法", "box": `+apiBody+`}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	testCases := []struct {
+		Method   string
+		Path     string
+		Body     string
+		Code     int
+		Contains string
+	}{
+		{http.MethodPost, "/api/v1/totalcosting/statement", apiBody, http.StatusOK, "月末仕掛品の評価は平均法によること。"},
+		{http.MethodPost, "/api/v1/totalcosting/statement?lang=en", apiBody, http.StatusOK, "the weighted average method"},
+		{http.MethodGet, "/api/v1/problems/1/statement", "", http.StatusOK, "  月初仕掛品    300個  (60%)\n"},
+		{http.MethodPost, "/api/v1/totalcosting/statement?lang=fr", apiBody, http.StatusBadRequest, "unknown language"},
+		{http.MethodPost, "/api/v1/totalcosting/statement", `{"master": []}`, http.StatusUnprocessableEntity, "validation failed"},
+		{http.MethodGet, "/api/v1/problems/2/statement", "", http.StatusNotFound, "not found"},
+	}
+
+	for _, testCase := range testCases {
+		w := do(testCase.Method, testCase.Path, testCase.Body)
+
+		var body string
+		if w.Code == http.StatusOK {
+			var res statementResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			body = res.Statement
+		} else {
+			body = w.Body.String()
+		}
+
+		if w.Code != testCase.Code || !strings.Contains(body, testCase.Contains) {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, w.Code, body)
+		}
+	}
+}
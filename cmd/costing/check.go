@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/answer"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/diagnosis"
+)
+
+// check is 問題ファイルを解いて解答ファイルを採点する
+// 入力や検証のエラーは1, 使い方の誤りは2を返す
+func check(args []string, stdout io.Writer, stderr io.Writer) int {
+	return grade("check", args, stdout, stderr)
+}
+
+// diagnose is 解答ファイルを採点し, 誤りがあればよくある誤りのどれで説明できるかを表示する
+// 入力や検証のエラーは1, 使い方の誤りは2を返す
+func diagnose(args []string, stdout io.Writer, stderr io.Writer) int {
+	return grade("diagnose", args, stdout, stderr)
+}
+
+// grade is checkとdiagnoseの共通の処理
+func grade(name string, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "table", "出力形式(table, json)")
+	tolerance := flags.Float64("tolerance", answer.DefaultTolerance, "正解とみなす差")
+
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(files) != 2 || (*format != "table" && *format != "json") {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	box, err := loadBox(files[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := box.Validate(); err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	a, err := loadAnswer(files[1])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	// 診断は誤った前提で解き直すのでRun前のBoxを渡す
+	var output interface{}
+	var d diagnosis.Diagnosis
+	if name == "diagnose" {
+		d, err = diagnosis.Diagnose(box, a, *tolerance)
+		output = d
+	} else {
+		box.Run()
+		d.Result, err = answer.Check(box, a, *tolerance)
+		output = d.Result
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(output); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	writeCheckResult(stdout, d.Result)
+	if name == "diagnose" {
+		writeDiagnosis(stdout, d)
+	}
+
+	return 0
+}
+
+// loadAnswer is 解答ファイルを読み込む
+// -なら標準入力から読む
+func loadAnswer(name string) (answer.Answer, error) {
+	if name == "-" {
+		return answer.Decode(os.Stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return answer.Answer{}, err
+	}
+	defer f.Close()
+
+	return answer.Decode(f)
+}
+
+// writeCheckResult is 採点結果を1項目1行で書き出す
+func writeCheckResult(w io.Writer, result answer.Result) {
+	for _, it := range result.Items {
+		mark := "○"
+		if !it.Correct {
+			mark = "×"
+		}
+		fmt.Fprintf(w, "%s %s%15s  (正解 %s)\n", mark, boxdiagram.PadRight(it.Label, 36),
+			costreport.FormatPrice(it.Answer), costreport.FormatPrice(it.Expected))
+	}
+
+	fmt.Fprintf(w, "%d / %d 正解\n", result.Correct, result.Total)
+}
+
+// writeDiagnosis is 解答の誤りを説明できる誤りを書き出す
+func writeDiagnosis(w io.Writer, d diagnosis.Diagnosis) {
+	if d.Result.Correct == d.Result.Total {
+		return
+	}
+	if len(d.Matches) == 0 {
+		fmt.Fprintln(w, "よくある誤りでは説明できない")
+		return
+	}
+
+	labels := make(map[string]string)
+	for _, it := range d.Result.Items {
+		labels[it.Key] = it.Label
+	}
+
+	fmt.Fprintln(w, "考えられる誤り:")
+	for _, m := range d.Matches {
+		explained := make([]string, len(m.Explained))
+		for i, key := range m.Explained {
+			explained[i] = labels[key]
+		}
+
+		note := "が一致"
+		if m.Complete {
+			note = "が一致(すべての誤りを説明できる)"
+		}
+		fmt.Fprintf(w, "- %s: %s%s\n", m.Description, strings.Join(explained, ", "), note)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/answer"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/diagnosis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"check", "testdata/problem.yaml", "testdata/answer.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, `○ 完成品原価                                1,872,000  (正解 1,872,000)
+○ 完成品単位原価                                1,300  (正解 1,300)
+× 月末仕掛品原価                              180,000  (正解 186,000)
+○ 原価要素2 当月投入の完成品換算量              1,332  (正解 1,332)
+○ 原価要素2 月末仕掛品の完成品換算量               72  (正解 72)
+4 / 5 正解
+`, stdout.String())
+
+	stdout.Reset()
+	code = run([]string{"check", "--format", "json", "--tolerance", "10000", "testdata/problem.yaml", "testdata/answer.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	var result answer.Result
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+	assert.Equal(t, 5, result.Correct)
+}
+
+func TestDiagnose(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"diagnose", "testdata/problem.yaml", "testdata/fifo_answer.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "0 / 3 正解\n考えられる誤り:\n"+
+		"- 原価要素2を平均法ではなく先入先出法で計算した: 完成品原価, 完成品単位原価, 月末仕掛品原価が一致(すべての誤りを説明できる)\n")
+
+	stdout.Reset()
+	code = run([]string{"diagnose", "--format", "json", "testdata/problem.yaml", "testdata/answer.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	var d diagnosis.Diagnosis
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &d))
+	assert.Equal(t, 4, d.Result.Correct)
+	assert.Empty(t, d.Matches)
+
+	stdout.Reset()
+	code = run([]string{"diagnose", "testdata/problem.yaml", "testdata/answer.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.True(t, strings.HasSuffix(stdout.String(), "4 / 5 正解\nよくある誤りでは説明できない\n"))
+}
+
+func TestCheckError(t *testing.T) {
+	testCases := []struct {
+		Args []string
+		Code int
+	}{
+		{[]string{"check", "testdata/problem.yaml"}, 2},
+		{[]string{"check", "--format", "xml", "testdata/problem.yaml", "testdata/answer.yaml"}, 2},
+		{[]string{"check", "testdata/invalid.yaml", "testdata/answer.yaml"}, 1},
+		{[]string{"check", "testdata/problem.yaml", "testdata/missing.yaml"}, 1},
+		{[]string{"check", "testdata/problem.yaml", "testdata/problem.yaml"}, 1},
+		{[]string{"check", "--tolerance", "-1", "testdata/problem.yaml", "testdata/answer.yaml"}, 1},
+		{[]string{"diagnose", "testdata/problem.yaml"}, 2},
+		{[]string{"diagnose", "--tolerance", "-1", "testdata/problem.yaml", "testdata/answer.yaml"}, 1},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+
+		code := run(testCase.Args, &stdout, &stderr)
+		if code != testCase.Code {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, code, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/generator"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// generate is 論点を指定して問題を生成し, JSONで書き出す
+// シードを指定しなければ時刻から決めて標準エラー出力に表示する
+// 入力のエラーは1, 使い方の誤りは2を返す
+func generate(args []string, stdout io.Writer, stderr io.Writer) int {
+	defaults := generator.DefaultOptions(time.Now().UnixNano())
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	seed := flags.Int64("seed", defaults.Seed, "乱数のシード(同じシードなら同じ問題)")
+	method := flags.String("method", defaults.CMethod.String(), "月末仕掛品の計算方法(FIFO, AVG)")
+	defectMethod := flags.String("defect-method", defaults.DMethod.String(), "正常仕損の計算方法(Neglecting, NonNeglecting)")
+	material := flags.String("material", string(defaults.Material), "直接材料の投入方法(start, point, uniform)")
+	defect := flags.String("defect", string(defaults.Defect), "正常仕損(none, both, output)")
+	difficulty := flags.String("difficulty", string(defaults.Difficulty), "難易度(easy, normal, hard)")
+
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(files) != 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	o := generator.Options{
+		Seed:       *seed,
+		Material:   generator.Material(*material),
+		Defect:     generator.Defect(*defect),
+		Difficulty: generator.Difficulty(*difficulty),
+	}
+	if err := o.CMethod.UnmarshalText([]byte(*method)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := o.DMethod.UnmarshalText([]byte(*defectMethod)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	box, err := generator.Generate(o)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	seeded := false
+	flags.Visit(func(f *flag.Flag) {
+		seeded = seeded || f.Name == "seed"
+	})
+	if !seeded {
+		fmt.Fprintf(stderr, "seed: %d\n", o.Seed)
+	}
+
+	if err := totalcosting.EncodeBox(stdout, box); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
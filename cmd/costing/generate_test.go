@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"generate", "--seed", "7", "--method", "先入先出法", "--defect-method", "NonNeglecting",
+		"--material", "point", "--defect", "output", "--difficulty", "easy"}
+	code := run(args, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stderr.String())
+
+	box, err := totalcosting.DecodeBox(bytes.NewReader(stdout.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, totalcosting.FIFO, box.Costs[0].CMethod)
+	assert.Equal(t, totalcosting.NonNeglecting, box.Costs[0].DMethod)
+
+	box.Run()
+	assert.Equal(t, box.ProductAvgCost, float64(int(box.ProductAvgCost)))
+
+	// 同じシードなら同じ問題
+	first := stdout.String()
+	stdout.Reset()
+	run(args, &stdout, &stderr)
+	assert.Equal(t, first, stdout.String())
+
+	// シードを省略したら表示する
+	stdout.Reset()
+	code = run([]string{"generate"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Regexp(t, `^seed: -?\d+\n$`, stderr.String())
+}
+
+func TestGenerateError(t *testing.T) {
+	testCases := []struct {
+		Args []string
+		Code int
+	}{
+		{[]string{"generate", "problem.yaml"}, 2},
+		{[]string{"generate", "--seed", "x"}, 2},
+		{[]string{"generate", "--method", "LIFO"}, 1},
+		{[]string{"generate", "--defect-method", "x"}, 1},
+		{[]string{"generate", "--material", "end"}, 1},
+		{[]string{"generate", "--defect", "x"}, 1},
+		{[]string{"generate", "--difficulty", "x"}, 1},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+		code := run(testCase.Args, &stdout, &stderr)
+		if code != testCase.Code {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d", testCase, code)
+		}
+	}
+}
@@ -1,28 +1,116 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/library"
 	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
-	gin "github.com/gin-gonic/gin"
 )
 
+const usage = `使い方:
+  costing                     Webサーバーを起動する
+  costing serve [--library dir]
+                              Webサーバーを起動する
+  costing solve [--format table|json|csv|xlsx|svg|text|ascii|latex] problem.yaml
+                              問題ファイルを解いて結果を表示する
+  costing solve [--format table|json|csv|xlsx|svg|text|ascii|latex] --master-csv master.csv --costs-csv costs.csv
+                              物量と原価のCSVを読み込んで解く
+  costing solve --format pdf --font font.ttf [--company 会社] [--plant 工場] [--period 期間] problem.yaml
+                              報告書をPDFで書き出す
+  costing solve --format markdown|html [--lang ja|en] problem.yaml
+                              報告書をMarkdownまたはHTMLで書き出す
+  costing check [--tolerance 0.5] [--format table|json] problem.yaml answer.yaml
+                              解答ファイルを採点する
+  costing diagnose [--tolerance 0.5] [--format table|json] problem.yaml answer.yaml
+                              解答ファイルを採点し, 誤りの原因を推測する
+  costing generate [--seed n] [--method FIFO|AVG] [--defect-method Neglecting|NonNeglecting]
+                   [--material start|point|uniform] [--defect none|both|output] [--difficulty easy|normal|hard]
+                              論点を指定して答えが割り切れる問題を生成する
+  costing statement [--lang ja|en] problem.yaml
+                              問題ファイルから日商簿記の形式の問題文を書き出す
+  costing problem list [--tags a,b] [--q 語句] [--offset n] [--limit n]
+                              問題集の問題を一覧する
+  costing problem get ID      問題集の問題をJSONで表示する
+  costing problem add --title タイトル [--statement 問題文] [--tags a,b] problem.yaml
+                              問題集に問題を追加する
+  costing problem update [--title タイトル] [--statement 問題文] [--tags a,b] ID [problem.yaml]
+                              問題集の問題を書き換える
+  costing problem delete ID   問題集の問題を削除する
+                              問題集のディレクトリは --library(既定は環境変数 COSTING_LIBRARY または problems)
+`
+
 func main() {
-	message := "原価計算しよう"
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run is サブコマンドを実行して終了コードを返す
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		return serve(nil, stdout, stderr)
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve(args[1:], stdout, stderr)
+	case "solve":
+		return solve(args[1:], stdout, stderr)
+	case "check":
+		return check(args[1:], stdout, stderr)
+	case "diagnose":
+		return diagnose(args[1:], stdout, stderr)
+	case "generate":
+		return generate(args[1:], stdout, stderr)
+	case "statement":
+		return statement(args[1:], stdout, stderr)
+	case "problem":
+		return problem(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
 
-	fmt.Println(message)
+	return 2
+}
+
+// serve is Webサーバーを起動する
+func serve(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("library", defaultLibrary(), "問題集のディレクトリ")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	store, err := library.Open(*dir)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
 
-	router := gin.Default()
-	router.LoadHTMLGlob("web/*.html")
+	fmt.Fprintln(stdout, "原価計算しよう")
 
-	router.GET("/", func(ctx *gin.Context) {
-		ctx.HTML(200, "index.html", gin.H{
-			"data": message,
-		})
-	})
+	if err := newRouter(store).Run(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
 
-	router.Run()
+// printError is エラーを表示する
+// 検証エラーは項目ごとに1行ずつ表示する
+func printError(stderr io.Writer, err error) {
+	if errs, ok := err.(totalcosting.ValidationError); ok {
+		for _, e := range errs {
+			fmt.Fprintf(stderr, "%s: %s\n", e.Field, e.Message)
+		}
+		return
+	}
 
-	var box totalcosting.Box
-	box.Run()
+	fmt.Fprintln(stderr, err)
 }
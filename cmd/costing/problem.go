@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/library"
+)
+
+// defaultLibrary is 問題集のディレクトリの既定値
+func defaultLibrary() string {
+	if dir := os.Getenv("COSTING_LIBRARY"); dir != "" {
+		return dir
+	}
+
+	return "problems"
+}
+
+// problemCommands is problemのサブコマンド
+// フラグは各サブコマンドでflagsに加える
+var problemCommands = map[string]func(flags *flag.FlagSet, args []string, stdout io.Writer, stderr io.Writer) int{
+	"list":   listProblems,
+	"get":    getProblem,
+	"add":    addProblem,
+	"update": updateProblem,
+	"delete": deleteProblem,
+}
+
+// problem is 問題集を操作する
+// 入力や検証のエラーは1, 使い方の誤りは2を返す
+func problem(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	command, ok := problemCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown problem command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("problem "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	return command(flags, args[1:], stdout, stderr)
+}
+
+// openLibrary is フラグを解釈して問題集を開き, 位置引数を返す
+// 位置引数の数がminからmaxの間でなければ使い方を表示する
+// 失敗したときは終了コードを返す
+func openLibrary(flags *flag.FlagSet, args []string, min int, max int, stderr io.Writer) (*library.Store, []string, int) {
+	dir := flags.String("library", defaultLibrary(), "問題集のディレクトリ")
+
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return nil, nil, 2
+	}
+	if len(files) < min || len(files) > max {
+		fmt.Fprint(stderr, usage)
+		return nil, nil, 2
+	}
+
+	store, err := library.Open(*dir)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return nil, nil, 1
+	}
+
+	return store, files, 0
+}
+
+// splitTags is カンマ区切りのタグを分ける
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// listProblems is 問題を一覧する
+func listProblems(flags *flag.FlagSet, args []string, stdout io.Writer, stderr io.Writer) int {
+	tags := flags.String("tags", "", "カンマ区切りのタグ(すべてを持つ問題に絞る)")
+	text := flags.String("q", "", "タイトルか問題文に含む語句")
+	offset := flags.Int("offset", 0, "読み飛ばす件数")
+	limit := flags.Int("limit", library.DefaultLimit, "表示する件数")
+
+	store, _, code := openLibrary(flags, args, 0, 0, stderr)
+	if store == nil {
+		return code
+	}
+
+	page, err := store.List(library.Query{Tags: splitTags(*tags), Text: *text, Offset: *offset, Limit: *limit})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	for _, p := range page.Problems {
+		fmt.Fprintf(stdout, "%6s  %s  %s\n", p.ID, boxdiagram.PadRight(p.Title, 30), strings.Join(p.Tags, ", "))
+	}
+
+	first, last := 0, page.Offset+len(page.Problems)
+	if len(page.Problems) > 0 {
+		first = page.Offset + 1
+	}
+	fmt.Fprintf(stdout, "%d-%d / %d件\n", first, last, page.Total)
+
+	return 0
+}
+
+// getProblem is 問題をJSONで表示する
+func getProblem(flags *flag.FlagSet, args []string, stdout io.Writer, stderr io.Writer) int {
+	store, files, code := openLibrary(flags, args, 1, 1, stderr)
+	if store == nil {
+		return code
+	}
+
+	p, err := store.Get(files[0])
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(p); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// addProblem is 問題ファイルを問題集に追加してIDを表示する
+func addProblem(flags *flag.FlagSet, args []string, stdout io.Writer, stderr io.Writer) int {
+	title := flags.String("title", "", "タイトル")
+	statement := flags.String("statement", "", "問題文")
+	tags := flags.String("tags", "", "カンマ区切りのタグ")
+
+	store, files, code := openLibrary(flags, args, 1, 1, stderr)
+	if store == nil {
+		return code
+	}
+
+	box, err := loadBox(files[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	p, err := store.Create(library.Problem{Title: *title, Statement: *statement, Tags: splitTags(*tags), Box: box})
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	fmt.Fprintln(stdout, p.ID)
+
+	return 0
+}
+
+// updateProblem is 問題のうち指定した項目だけを書き換える
+// 問題ファイルを指定すればBoxも置き換える
+func updateProblem(flags *flag.FlagSet, args []string, stdout io.Writer, stderr io.Writer) int {
+	title := flags.String("title", "", "タイトル")
+	statement := flags.String("statement", "", "問題文")
+	tags := flags.String("tags", "", "カンマ区切りのタグ")
+
+	store, files, code := openLibrary(flags, args, 1, 2, stderr)
+	if store == nil {
+		return code
+	}
+
+	p, err := store.Get(files[0])
+	if err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			p.Title = *title
+		case "statement":
+			p.Statement = *statement
+		case "tags":
+			p.Tags = splitTags(*tags)
+		}
+	})
+
+	if len(files) == 2 {
+		if p.Box, err = loadBox(files[1]); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	if _, err := store.Update(p.ID, p); err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// deleteProblem is 問題を削除する
+func deleteProblem(flags *flag.FlagSet, args []string, stdout io.Writer, stderr io.Writer) int {
+	store, files, code := openLibrary(flags, args, 1, 1, stderr)
+	if store == nil {
+		return code
+	}
+
+	if err := store.Delete(files[0]); err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	return 0
+}
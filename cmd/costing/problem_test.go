@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "problems")
+
+	commands := []struct {
+		Args   []string
+		Output string
+	}{
+		{[]string{"problem", "add", "--library", dir, "--title", "平均法", "--tags", "平均法,工程別", "testdata/problem.yaml"}, "1\n"},
+		{[]string{"problem", "add", "testdata/problem.yaml", "--title", "先入先出法", "--statement", "FIFOで解く", "--library", dir}, "2\n"},
+		{[]string{"problem", "update", "--library", dir, "--tags", "FIFO", "2"}, ""},
+		{[]string{"problem", "list", "--library", dir, "--tags", "fifo"}, "     2  先入先出法                      FIFO\n1-1 / 1件\n"},
+		{[]string{"problem", "list", "--library", dir, "--q", "平均", "--limit", "1"}, "     1  平均法                          平均法, 工程別\n1-1 / 1件\n"},
+		{[]string{"problem", "delete", "--library", dir, "1"}, ""},
+		{[]string{"problem", "list", "--library", dir, "--offset", "5"}, "0-5 / 1件\n"},
+	}
+
+	for _, command := range commands {
+		var stdout, stderr bytes.Buffer
+
+		code := run(command.Args, &stdout, &stderr)
+		if code != 0 || stdout.String() != command.Output {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %q %s", command, code, stdout.String(), stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"problem", "get", "--library", dir, "2"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), `"statement": "FIFOで解く"`)
+	assert.Contains(t, stdout.String(), `"tags": [`)
+}
+
+func TestProblemError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "problems")
+
+	testCases := []struct {
+		Args []string
+		Code int
+	}{
+		{[]string{"problem"}, 2},
+		{[]string{"problem", "copy"}, 2},
+		{[]string{"problem", "get", "--library", dir}, 2},
+		{[]string{"problem", "get", "--library", dir, "1"}, 1},
+		{[]string{"problem", "delete", "--library", dir, "1"}, 1},
+		{[]string{"problem", "add", "--library", dir, "testdata/problem.yaml"}, 1},
+		{[]string{"problem", "add", "--library", dir, "--title", "x", "testdata/invalid.yaml"}, 1},
+		{[]string{"problem", "update", "--library", dir, "1", "a.yaml", "b.yaml"}, 2},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+
+		code := run(testCase.Args, &stdout, &stderr)
+		if code != testCase.Code {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %s", testCase, code, stderr.String())
+		}
+	}
+}
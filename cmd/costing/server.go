@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/library"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/web"
+	gin "github.com/gin-gonic/gin"
+)
+
+// newRouter is Webサーバーのルーティングを作る
+// 画面は実行ファイルに埋め込んだものを返す
+// storeがnilなら問題集のAPIは使えない
+func newRouter(store *library.Store) *gin.Engine {
+	router := gin.Default()
+
+	router.GET("/", index)
+	router.POST("/diagram.svg", limitBody(maxRequestBytes), diagramSVG)
+	addAPI(router, store)
+
+	return router
+}
+
+// index is 問題を入力して解く画面を返す
+func index(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", web.Index)
+}
+
+// diagramSVG is JSONの問題を解いてBox図のSVGを返す
+func diagramSVG(ctx *gin.Context) {
+	body, ok := readBody(ctx)
+	if !ok {
+		return
+	}
+
+	box, err := totalcosting.DecodeBox(bytes.NewReader(body))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := box.Validate(); err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	box.Run()
+
+	ctx.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(boxdiagram.SVG(box)))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	gin "github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func TestDiagramSVG(t *testing.T) {
+	body := `{
+  "master": [
+    {"type": "First", "unit": 300, "progress": 0.6},
+    {"type": "Input", "unit": 1380},
+    {"type": "Output", "unit": 1440},
+    {"type": "Last", "unit": 240, "progress": 0.3}
+  ],
+  "costs": [
+    {"input_on_avg": true, "calculation_method": "AVG", "defective_product_method": "NonNeglecting",
+     "first_cost": 161640, "input_cost": 972360}
+  ]
+}`
+
+	testCases := []struct {
+		Body string
+		Code int
+	}{
+		{body, http.StatusOK},
+		{`{"master": [}`, http.StatusBadRequest},
+		{`{"master": [], "costs": []}`, http.StatusBadRequest},
+		{strings.Repeat(" ", maxRequestBytes+1), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, testCase := range testCases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/diagram.svg", strings.NewReader(testCase.Body))
+		newRouter(nil).ServeHTTP(w, req)
+
+		assert.Equal(t, testCase.Code, w.Code, w.Body.String())
+		if testCase.Code == http.StatusOK {
+			assert.Equal(t, "image/svg+xml; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), "<svg")
+		}
+	}
+}
+
+func TestDiagramSVGLimitBody(t *testing.T) {
+	// Content-Lengthのないリクエストも読み込みで制限する
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/diagram.svg", strings.NewReader(strings.Repeat(" ", maxRequestBytes+1)))
+	req.ContentLength = -1
+	newRouter(nil).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestIndex(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	newRouter(nil).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "api/v1/totalcosting/solve")
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/csvimport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/pdfreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/report"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/xlsx"
+)
+
+// result is solveの出力項目
+type result struct {
+	ProductTotalCost float64 `json:"product_total_cost"`
+	ProductAvgCost   float64 `json:"product_avg_cost"`
+	EOTMTotalCost    float64 `json:"eotm_total_cost"`
+}
+
+// formats is solveの出力形式ごとの書き出し処理
+var formats = map[string]func(w io.Writer, box totalcosting.Box) error{
+	"table": writeTable,
+	"json":  writeJSON,
+	"csv":   writeCSV,
+	"xlsx":  xlsx.Write,
+	"svg":   boxdiagram.WriteSVG,
+	"text":  boxdiagram.WriteText,
+	"ascii": writeASCII,
+	"latex": report.WriteLaTeX,
+}
+
+// solve is 問題ファイルを解いて結果を表示する
+// 入力や検証のエラーは1, 使い方の誤りは2を返す
+func solve(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("solve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "table", "出力形式(table, json, csv, xlsx, svg, text, ascii, latex, pdf, markdown, html)")
+	lang := flags.String("lang", "ja", "Markdown, HTMLの報告書の言語(ja, en)")
+	var pdfOptions pdfreport.Options
+	flags.StringVar(&pdfOptions.FontPath, "font", os.Getenv("COSTING_FONT"), "PDFに埋め込む日本語のTrueTypeフォント")
+	flags.StringVar(&pdfOptions.Header.Company, "company", "", "PDFの見出しの会社名")
+	flags.StringVar(&pdfOptions.Header.Plant, "plant", "", "PDFの見出しの工場名")
+	flags.StringVar(&pdfOptions.Header.Period, "period", "", "PDFの見出しの会計期間")
+	masterCSV := flags.String("master-csv", "", "物量のCSV")
+	costsCSV := flags.String("costs-csv", "", "原価のCSV")
+
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return 2
+	}
+
+	// CSVから読み込むときは問題ファイルを指定しない
+	useCSV := *masterCSV != "" || *costsCSV != ""
+	if useCSV && (*masterCSV == "" || *costsCSV == "" || len(files) != 0) {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if !useCSV && len(files) != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	l, err := report.ParseLang(*lang)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	write, ok := formats[*format]
+	switch *format {
+	case "pdf":
+		write, ok = func(w io.Writer, box totalcosting.Box) error {
+			return pdfreport.Write(w, []pdfreport.Process{{Box: box}}, pdfOptions)
+		}, true
+	case "markdown":
+		write, ok = func(w io.Writer, box totalcosting.Box) error {
+			return report.WriteMarkdown(w, box, l)
+		}, true
+	case "html":
+		write, ok = func(w io.Writer, box totalcosting.Box) error {
+			return report.WriteHTML(w, box, l)
+		}, true
+	}
+	if !ok {
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return 2
+	}
+
+	var box totalcosting.Box
+	var name string
+	if useCSV {
+		name = *masterCSV
+		box, err = csvimport.New().ImportFiles(*masterCSV, *costsCSV)
+	} else {
+		name = files[0]
+		box, err = loadBox(name)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if err := box.Validate(); err != nil {
+		if errs, ok := err.(totalcosting.ValidationError); ok {
+			for _, e := range errs {
+				fmt.Fprintf(stderr, "%s: %s: %s\n", name, e.Field, e.Message)
+			}
+		} else {
+			fmt.Fprintln(stderr, err)
+		}
+		return 1
+	}
+
+	box.Run()
+
+	if err := write(stdout, box); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// parseArgs is ファイル名の後ろに書かれたフラグも解釈して位置引数を返す
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// loadBox is 問題ファイルを読み込む
+// 拡張子が.jsonならJSON, .xlsxならテンプレートのブック, それ以外はYAMLとして読む
+// -なら標準入力からYAMLを読む
+func loadBox(name string) (totalcosting.Box, error) {
+	if name == "-" {
+		return totalcosting.DecodeBoxYAML(os.Stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return totalcosting.Box{}, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return totalcosting.DecodeBox(f)
+	case ".xlsx":
+		info, err := f.Stat()
+		if err != nil {
+			return totalcosting.Box{}, err
+		}
+		return xlsx.Import(f, info.Size())
+	}
+
+	return totalcosting.DecodeBoxYAML(f)
+}
+
+// newResult is Run済みのBoxから出力項目を取り出す
+func newResult(box totalcosting.Box) result {
+	return result{
+		ProductTotalCost: box.ProductTotalCost,
+		ProductAvgCost:   box.ProductAvgCost,
+		EOTMTotalCost:    box.EOTMTotalCost,
+	}
+}
+
+// writeTable is 結果を表形式で書き出す
+func writeTable(w io.Writer, box totalcosting.Box) error {
+	r := newResult(box)
+	rows := [][2]string{
+		{"完成品原価", costreport.FormatYen(r.ProductTotalCost)},
+		{"完成品単位原価", costreport.FormatPrice(r.ProductAvgCost)},
+		{"月末仕掛品原価", costreport.FormatYen(r.EOTMTotalCost)},
+	}
+
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "%s%15s\n", boxdiagram.PadRight(row[0], 16), row[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeASCII is Box図をASCII文字で書き出す
+func writeASCII(w io.Writer, box totalcosting.Box) error {
+	_, err := io.WriteString(w, boxdiagram.Text(box, boxdiagram.ASCII))
+
+	return err
+}
+
+// writeJSON is 結果をJSONで書き出す
+func writeJSON(w io.Writer, box totalcosting.Box) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(newResult(box))
+}
+
+// writeCSV is 結果をCSVで書き出す
+func writeCSV(w io.Writer, box totalcosting.Box) error {
+	r := newResult(box)
+	writer := csv.NewWriter(w)
+
+	records := [][]string{
+		{"item", "amount"},
+		{"product_total_cost", formatRaw(r.ProductTotalCost)},
+		{"product_avg_cost", formatRaw(r.ProductAvgCost)},
+		{"eotm_total_cost", formatRaw(r.EOTMTotalCost)},
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+// formatRaw is 数値を区切りなしで表示する
+func formatRaw(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/xlsx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolve(t *testing.T) {
+	testCases := []struct {
+		Args   []string
+		Output string
+	}{
+		{
+			[]string{"solve", "testdata/problem.yaml"},
+			"完成品原価            1,872,000\n完成品単位原価            1,300\n月末仕掛品原価          186,000\n",
+		},
+		{
+			[]string{"solve", "testdata/problem.yaml", "--format", "csv"},
+			"item,amount\nproduct_total_cost,1872000\nproduct_avg_cost,1300\neotm_total_cost,186000\n",
+		},
+		{
+			[]string{"solve", "--format", "csv",
+				"--master-csv", "../../internal/apps/csvimport/testdata/master.csv",
+				"--costs-csv", "../../internal/apps/csvimport/testdata/costs.csv"},
+			"item,amount\nproduct_total_cost,1872000\nproduct_avg_cost,1300\neotm_total_cost,186000\n",
+		},
+		{
+			[]string{"solve", "--format=json", "testdata/problem.yaml"},
+			"{\n  \"product_total_cost\": 1872000,\n  \"product_avg_cost\": 1300,\n  \"eotm_total_cost\": 186000\n}\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+
+		code := run(testCase.Args, &stdout, &stderr)
+		assert.Equal(t, 0, code, stderr.String())
+		assert.Equal(t, testCase.Output, stdout.String())
+	}
+}
+
+func TestSolveBinary(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"solve", "--format", "xlsx", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.True(t, bytes.HasPrefix(stdout.Bytes(), []byte("PK")))
+
+	stdout.Reset()
+	code = run([]string{"solve", "--format", "svg", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.True(t, strings.HasPrefix(stdout.String(), "<svg"))
+
+	stdout.Reset()
+	code = run([]string{"solve", "--format", "ascii", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "| 月初仕掛品")
+
+	stdout.Reset()
+	code = run([]string{"solve", "--format", "markdown", "--lang", "en", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "| Cost of goods completed | 1,872,000 |")
+
+	stdout.Reset()
+	code = run([]string{"solve", "--format", "latex", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), `\begin{tikzpicture}`)
+
+	stdout.Reset()
+	code = run([]string{"solve", "--format", "html", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.True(t, strings.HasPrefix(stdout.String(), "<!DOCTYPE html>"))
+
+	if _, err := os.Stat("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"); err == nil {
+		stdout.Reset()
+		code = run([]string{"solve", "--format", "pdf", "--font", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+			"--company", "Costing", "testdata/problem.yaml"}, &stdout, &stderr)
+		assert.Equal(t, 0, code, stderr.String())
+		assert.True(t, strings.HasPrefix(stdout.String(), "%PDF-"))
+	}
+}
+
+func TestSolveTemplate(t *testing.T) {
+	f, err := os.Open("testdata/problem.yaml")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	box, err := totalcosting.DecodeBoxYAML(f)
+	assert.NoError(t, err)
+
+	name := filepath.Join(t.TempDir(), "problem.xlsx")
+	assert.NoError(t, xlsx.Template(box).WriteFile(name))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"solve", "--format", "csv", name}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "item,amount\nproduct_total_cost,1872000\nproduct_avg_cost,1300\neotm_total_cost,186000\n", stdout.String())
+}
+
+func TestSolveError(t *testing.T) {
+	testCases := []struct {
+		Args []string
+		Code int
+	}{
+		{[]string{"solve"}, 2},
+		{[]string{"solve", "--format", "xml", "testdata/problem.yaml"}, 2},
+		{[]string{"solve", "testdata/missing.yaml"}, 1},
+		{[]string{"solve", "testdata/invalid.yaml"}, 1},
+		{[]string{"solve", "--master-csv", "master.csv"}, 2},
+		{[]string{"solve", "--master-csv", "m.csv", "--costs-csv", "c.csv", "testdata/problem.yaml"}, 2},
+		{[]string{"solve", "--format", "pdf", "--font", "", "testdata/problem.yaml"}, 1},
+		{[]string{"solve", "--format", "html", "--lang", "fr", "testdata/problem.yaml"}, 2},
+		{[]string{"unknown"}, 2},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+
+		code := run(testCase.Args, &stdout, &stderr)
+		assert.Equal(t, testCase.Code, code, strings.Join(testCase.Args, " "))
+		assert.Empty(t, stdout.String())
+	}
+}
+
+func TestSolveValidationMessage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	run([]string{"solve", "testdata/invalid.yaml"}, &stdout, &stderr)
+	assert.Contains(t, stderr.String(), "testdata/invalid.yaml: master: left side units 1000 do not match right side units 900")
+	assert.Contains(t, stderr.String(), "testdata/invalid.yaml: costs[0].input_cost: must not be negative")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/report"
+)
+
+// statement is 問題ファイルから日商簿記の形式の問題文を書き出す
+// 入力や検証のエラーは1, 使い方の誤りは2を返す
+func statement(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("statement", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	lang := flags.String("lang", "ja", "問題文の言語(ja, en)")
+
+	files, err := parseArgs(flags, args)
+	if err != nil {
+		return 2
+	}
+	if len(files) != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	l, err := report.ParseLang(*lang)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	box, err := loadBox(files[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := box.Validate(); err != nil {
+		printError(stderr, err)
+		return 1
+	}
+
+	if err := report.WriteStatement(stdout, box, l); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatement(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"statement", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "  月初仕掛品    300個  (60%)\n")
+	assert.Contains(t, stdout.String(), "  月初仕掛品原価  206,400円  161,640円\n")
+	assert.Contains(t, stdout.String(), "月末仕掛品の評価は平均法によること。")
+
+	stdout.Reset()
+	code = run([]string{"statement", "--lang", "en", "testdata/problem.yaml"}, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Ending work in process is valued by the weighted average method.")
+}
+
+func TestStatementError(t *testing.T) {
+	testCases := []struct {
+		Args []string
+		Code int
+	}{
+		{[]string{"statement"}, 2},
+		{[]string{"statement", "--lang", "fr", "testdata/problem.yaml"}, 2},
+		{[]string{"statement", "testdata/missing.yaml"}, 1},
+		{[]string{"statement", "testdata/invalid.yaml"}, 1},
+	}
+
+	for _, testCase := range testCases {
+		var stdout, stderr bytes.Buffer
+		code := run(testCase.Args, &stdout, &stderr)
+		if code != testCase.Code {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d", testCase, code)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 module github.com/KeisukeIwabuchi/Costing
 
-go 1.15
+go 1.16
 
 require (
 	github.com/gin-gonic/gin v1.6.3
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,214 @@
+package answer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultTolerance is 正解とみなす差の既定値
+// 円未満の端数処理の違いを許す
+const DefaultTolerance = 0.5
+
+// 解答の項目のキー
+// 計算過程(totalcosting.Trace)のキーと同じ
+const (
+	KeyProductTotalCost = "product_total_cost"
+	KeyProductAvgCost   = "product_avg_cost"
+	KeyEOTMTotalCost    = "eotm_total_cost"
+	KeyAbnormalLoss     = "abnormal_loss_cost"
+)
+
+// Answer is 学習者の解答
+// 省略した項目は採点しない
+type Answer struct {
+	ProductTotalCost *float64     `json:"product_total_cost,omitempty" yaml:"product_total_cost,omitempty"` // 完成品原価
+	ProductAvgCost   *float64     `json:"product_avg_cost,omitempty" yaml:"product_avg_cost,omitempty"`     // 完成品単位原価
+	EOTMTotalCost    *float64     `json:"eotm_total_cost,omitempty" yaml:"eotm_total_cost,omitempty"`       // 月末仕掛品原価
+	AbnormalLoss     *float64     `json:"abnormal_loss_cost,omitempty" yaml:"abnormal_loss_cost,omitempty"` // 異常仕損費(減損費)
+	Costs            []CostAnswer `json:"costs,omitempty" yaml:"costs,omitempty"`
+}
+
+// CostAnswer is 原価要素1つの解答
+type CostAnswer struct {
+	Units map[string]float64 `json:"units,omitempty" yaml:"units,omitempty"` // 要素の種別ごとの完成品換算量
+}
+
+// Item is 採点した項目1つ
+type Item struct {
+	Key      string  `json:"key"`
+	Label    string  `json:"label"`
+	Answer   float64 `json:"answer"`
+	Expected float64 `json:"expected"`
+	Correct  bool    `json:"correct"`
+}
+
+// Result is 採点結果
+type Result struct {
+	Items   []Item `json:"items"`
+	Correct int    `json:"correct"` // 正解の項目数
+	Total   int    `json:"total"`   // 採点した項目数
+}
+
+// Decode is JSONまたはYAMLの解答を読み込む
+// {で始まればJSON, それ以外はYAMLとして読む
+// 未知のフィールドがあればエラー
+func Decode(r io.Reader) (Answer, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Answer{}, fmt.Errorf("answer: read: %w", err)
+	}
+
+	var a Answer
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&a); err != nil {
+			return Answer{}, fmt.Errorf("answer: decode: %w", err)
+		}
+		return a, nil
+	}
+
+	if err := yaml.UnmarshalStrict(data, &a); err != nil {
+		return Answer{}, fmt.Errorf("answer: decode: %w", err)
+	}
+
+	return a, nil
+}
+
+// Check is Run済みのBoxと解答を比べて項目ごとに採点する
+// 差がtolerance以下なら正解とする
+func Check(box totalcosting.Box, a Answer, tolerance float64) (Result, error) {
+	if tolerance < 0 {
+		return Result{}, fmt.Errorf("answer: tolerance must not be negative")
+	}
+
+	expected, err := Expected(box)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	add := func(key string, label string, answer float64) {
+		e := expected[key]
+		item := Item{
+			Key:      key,
+			Label:    label,
+			Answer:   answer,
+			Expected: e,
+			Correct:  math.Abs(answer-e) <= tolerance,
+		}
+
+		result.Items = append(result.Items, item)
+		result.Total++
+		if item.Correct {
+			result.Correct++
+		}
+	}
+
+	for _, f := range []struct {
+		Key   string
+		Label string
+		Value *float64
+	}{
+		{KeyProductTotalCost, "完成品原価", a.ProductTotalCost},
+		{KeyProductAvgCost, "完成品単位原価", a.ProductAvgCost},
+		{KeyEOTMTotalCost, "月末仕掛品原価", a.EOTMTotalCost},
+		{KeyAbnormalLoss, "異常仕損費", a.AbnormalLoss},
+	} {
+		if f.Value != nil {
+			add(f.Key, f.Label, *f.Value)
+		}
+	}
+
+	if len(a.Costs) > len(box.Costs) {
+		return Result{}, fmt.Errorf("answer: %d costs answered but the problem has %d", len(a.Costs), len(box.Costs))
+	}
+
+	for i, c := range a.Costs {
+		units, err := c.units()
+		if err != nil {
+			return Result{}, fmt.Errorf("answer: costs[%d].units: %w", i, err)
+		}
+
+		// 種別の順に採点する
+		var types []totalcosting.ElementType
+		for t := range units {
+			types = append(types, t)
+		}
+		sort.Slice(types, func(j, k int) bool { return types[j] < types[k] })
+
+		for _, t := range types {
+			key := unitKey(i, t)
+			if _, ok := expected[key]; !ok {
+				return Result{}, fmt.Errorf("answer: costs[%d].units: %s is not in the problem", i, t)
+			}
+
+			add(key, fmt.Sprintf("%s %sの完成品換算量", box.CostName(i), t.Label()), units[t])
+		}
+	}
+
+	return result, nil
+}
+
+// units is 英語名か日本語名の種別ごとの完成品換算量を種別で引けるようにする
+func (c CostAnswer) units() (map[totalcosting.ElementType]float64, error) {
+	result := make(map[totalcosting.ElementType]float64)
+
+	for name, v := range c.Units {
+		var t totalcosting.ElementType
+		if err := t.UnmarshalText([]byte(name)); err != nil {
+			return nil, err
+		}
+		if _, ok := result[t]; ok {
+			return nil, fmt.Errorf("%s is answered twice", t)
+		}
+		result[t] = v
+	}
+
+	return result, nil
+}
+
+// Expected is Run済みのBoxから解答の項目ごとの正解を返す
+func Expected(box totalcosting.Box) (map[string]float64, error) {
+	if len(box.Trace) == 0 {
+		return nil, fmt.Errorf("answer: box has not been run")
+	}
+
+	expected := map[string]float64{
+		KeyProductTotalCost: box.ProductTotalCost,
+		KeyProductAvgCost:   box.ProductAvgCost,
+		KeyEOTMTotalCost:    box.EOTMTotalCost,
+		KeyAbnormalLoss:     0,
+	}
+
+	for i, c := range box.Costs {
+		for _, e := range c.Elements {
+			// 換算量は計算過程の値を正解にする
+			// 度外視法では正常仕損を除いた当月投入換算量になる
+			key := unitKey(i, e.Type)
+			expected[key] = float64(e.Unit)
+			if s, ok := box.Trace.Find(key); ok {
+				expected[key] = s.Value
+			}
+
+			if e.Type == totalcosting.AbnormalDefect || e.Type == totalcosting.AbnormalImpairment {
+				expected[KeyAbnormalLoss] += e.Cost()
+			}
+		}
+	}
+
+	return expected, nil
+}
+
+// unitKey is 完成品換算量の項目のキーを返す
+func unitKey(i int, t totalcosting.ElementType) string {
+	return fmt.Sprintf("costs[%d].units.%s", i, t)
+}
@@ -0,0 +1,143 @@
+package answer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAbnormalBox is 異常仕損のある問題
+func newAbnormalBox() totalcosting.Box {
+	box := totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.Input, Unit: 2000},
+			{Type: totalcosting.Output, Unit: 1700},
+			{Type: totalcosting.AbnormalDefect, Unit: 100, Progress: 1},
+			{Type: totalcosting.Last, Unit: 200, Progress: 0.5},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "加工費", InputOnAvg: true, CMethod: totalcosting.AVG, DMethod: totalcosting.Neglecting, InputCost: 1900000},
+		},
+	}
+	box.Run()
+
+	return box
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestCheck(t *testing.T) {
+	box := newAbnormalBox()
+
+	a := Answer{
+		ProductTotalCost: float(1700000),
+		ProductAvgCost:   float(1000.4),
+		EOTMTotalCost:    float(110000),
+		AbnormalLoss:     float(100000),
+		Costs: []CostAnswer{
+			{Units: map[string]float64{"月末仕掛品": 100, "Input": 1900}},
+		},
+	}
+
+	result, err := Check(box, a, DefaultTolerance)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, result.Total)
+	assert.Equal(t, 5, result.Correct)
+
+	testCases := []struct {
+		Key      string
+		Expected float64
+		Correct  bool
+	}{
+		{KeyProductTotalCost, 1700000, true},
+		{KeyProductAvgCost, 1000, true},
+		{KeyEOTMTotalCost, 100000, false},
+		{KeyAbnormalLoss, 100000, true},
+		{"costs[0].units.Input", 1900, true},
+		{"costs[0].units.Last", 100, true},
+	}
+
+	for i, testCase := range testCases {
+		item := result.Items[i]
+		if item.Key != testCase.Key || item.Expected != testCase.Expected || item.Correct != testCase.Correct {
+			t.Errorf("Invalid result. testCase:%#v, actual:%#v", testCase, item)
+		}
+	}
+	assert.Equal(t, "加工費 月末仕掛品の完成品換算量", result.Items[5].Label)
+
+	// 許容誤差を0にすると端数の違いも不正解
+	result, err = Check(box, Answer{ProductAvgCost: float(1000.4)}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Correct)
+}
+
+func TestCheckNeglecting(t *testing.T) {
+	box := totalcostingtest.Solved(totalcostingtest.DefectBox(totalcosting.AVG, totalcosting.Neglecting))
+
+	// 度外視法・両者負担の当月投入換算量は正常仕損を除いて数える
+	a := Answer{
+		Costs: []CostAnswer{
+			{Units: map[string]float64{"当月投入": 1900, "月末仕掛品": 500}},
+			{Units: map[string]float64{"当月投入": 1940}},
+		},
+	}
+
+	result, err := Check(box, a, DefaultTolerance)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 2, result.Correct)
+	assert.Equal(t, Item{
+		Key:      "costs[1].units.Input",
+		Label:    "原価要素2 当月投入の完成品換算量",
+		Answer:   1940,
+		Expected: 1900,
+		Correct:  false,
+	}, result.Items[2])
+}
+
+func TestCheckError(t *testing.T) {
+	box := newAbnormalBox()
+
+	testCases := []struct {
+		Box       totalcosting.Box
+		Answer    Answer
+		Tolerance float64
+	}{
+		{totalcosting.Box{}, Answer{}, 0},
+		{box, Answer{}, -1},
+		{box, Answer{Costs: []CostAnswer{{}, {}}}, 0},
+		{box, Answer{Costs: []CostAnswer{{Units: map[string]float64{"Unknown": 1}}}}, 0},
+		{box, Answer{Costs: []CostAnswer{{Units: map[string]float64{"First": 1}}}}, 0},
+		{box, Answer{Costs: []CostAnswer{{Units: map[string]float64{"Last": 1, "月末仕掛品": 1}}}}, 0},
+	}
+
+	for _, testCase := range testCases {
+		if _, err := Check(testCase.Box, testCase.Answer, testCase.Tolerance); err == nil {
+			t.Errorf("Invalid result. testCase:%#v", testCase)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []string{
+		"{\n\t\"product_total_cost\": 1700000,\n\t\"costs\": [{\"units\": {\"Last\": 100}}]\n}",
+		"product_total_cost: 1700000\ncosts:\n  - units:\n      Last: 100\n",
+	}
+
+	for _, testCase := range testCases {
+		a, err := Decode(strings.NewReader(testCase))
+		if err != nil || *a.ProductTotalCost != 1700000 || a.Costs[0].Units["Last"] != 100 || a.ProductAvgCost != nil {
+			t.Errorf("Invalid result. testCase:%#v, actual:%v", testCase, err)
+		}
+	}
+
+	_, err := Decode(strings.NewReader(`{"unknown": 1}`))
+	assert.Error(t, err)
+	_, err = Decode(strings.NewReader("unknown: 1\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package boxdiagram
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Item is Box図に描く要素1つ
+type Item struct {
+	Type       totalcosting.ElementType
+	Unit       int     // 数量
+	Progress   float64 // 加工進捗度
+	Conversion int     // 換算量
+	Price      float64 // 単価(正常仕損費の負担後)
+	Cost       float64 // 原価
+	NDBurden   int     // 正常仕損の負担量
+}
+
+// IsBearer is 正常仕損費を負担しているか判定
+func (it Item) IsBearer() bool {
+	return it.NDBurden > 0
+}
+
+// IsNormalLoss is 正常仕損または正常減損か判定
+func (it Item) IsNormalLoss() bool {
+	return totalcosting.IsNormalLoss(it.Type)
+}
+
+// Diagram is 原価要素1つのBox図
+type Diagram struct {
+	Name   string
+	Method string  // 投入の仕方と計算方法
+	Price  float64 // 配分に使った単価
+	Left   []Item  // 月初仕掛品, 当月投入
+	Right  []Item  // 完成品, 仕損, 減損, 月末仕掛品
+}
+
+// Units is 左右の数量の合計のうち大きい方を返す
+func (d Diagram) Units() int {
+	left, right := 0, 0
+
+	for _, it := range d.Left {
+		left += it.Unit
+	}
+	for _, it := range d.Right {
+		right += it.Unit
+	}
+
+	if left > right {
+		return left
+	}
+
+	return right
+}
+
+// New is Run済みのBoxから原価要素ごとのBox図を作る
+func New(box totalcosting.Box) []Diagram {
+	diagrams := make([]Diagram, len(box.Costs))
+
+	for i, c := range box.Costs {
+		d := Diagram{Name: box.CostName(i)}
+
+		timing := "平均的投入"
+		if !c.InputOnAvg {
+			timing = fmt.Sprintf("定点投入(投入点%g)", c.InputTiming)
+		}
+		d.Method = timing + " / " + c.CMethod.Label() + " / " + c.DMethod.Label()
+
+		if s, ok := box.Trace.Find(fmt.Sprintf("costs[%d].price", i)); ok {
+			d.Price = s.Value
+		}
+
+		// 正常仕損がなければ負担量は意味を持たない
+		hasLoss := false
+		for _, e := range c.Elements {
+			if totalcosting.IsNormalLoss(e.Type) {
+				hasLoss = true
+			}
+		}
+
+		for j, e := range c.Elements {
+			it := Item{
+				Type:       e.Type,
+				Progress:   e.Progress,
+				Conversion: e.Unit,
+				Price:      e.Price,
+				Cost:       e.Cost(),
+				NDBurden:   e.NDBurden,
+			}
+			if !hasLoss {
+				it.NDBurden = 0
+			}
+			if j < len(box.Master) {
+				it.Unit = box.Master[j].Unit
+				it.Progress = box.Master[j].Progress
+			}
+
+			if e.IsLeftElement() {
+				// 左側は問題で与えられた原価
+				it.Cost = c.InputCost
+				if e.Type == totalcosting.First {
+					it.Cost = c.FirstCost
+				}
+				it.Price = 0
+				if it.Conversion > 0 {
+					it.Price = it.Cost / float64(it.Conversion)
+				}
+				d.Left = append(d.Left, it)
+			} else {
+				d.Right = append(d.Right, it)
+			}
+		}
+
+		diagrams[i] = d
+	}
+
+	return diagrams
+}
+
+// Lines is 要素に書く文字列を返す
+func (it Item) Lines() []string {
+	label := it.Type.Label()
+	if it.IsBearer() {
+		label += " (正常仕損費を負担)"
+	}
+
+	unit := "数量 " + costreport.FormatYen(float64(it.Unit))
+	if it.Type != totalcosting.Input && it.Type != totalcosting.Output {
+		unit += " (進捗度 " + strconv.FormatFloat(it.Progress, 'f', -1, 64) + ")"
+	}
+
+	result := []string{
+		label,
+		unit,
+		"換算量 " + costreport.FormatYen(float64(it.Conversion)),
+		"原価 " + costreport.FormatYen(it.Cost) + " (@" + costreport.FormatPrice(it.Price) + ")",
+	}
+
+	switch {
+	case it.IsNormalLoss():
+		result = append(result, "負担する要素に配分")
+	case it.IsBearer():
+		result = append(result, "負担量 "+costreport.FormatYen(float64(it.NDBurden)))
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package boxdiagram
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDefectBox is 正常仕損のある問題
+func newDefectBox() totalcosting.Box {
+	return totalcostingtest.DefectBox(totalcosting.AVG, totalcosting.Neglecting)
+}
+
+func TestNew(t *testing.T) {
+	box := newDefectBox()
+	box.Run()
+
+	diagrams := New(box)
+	assert.Equal(t, 2, len(diagrams))
+
+	material := diagrams[0]
+	assert.Equal(t, "直接材料費", material.Name)
+	assert.Equal(t, "定点投入(投入点0) / 平均法 / 度外視法", material.Method)
+	// 度外視法・両者負担の単価は正常仕損を除いた換算量で計算する
+	assert.Equal(t, 460000.0/2300, material.Price)
+	assert.Equal(t, 2, len(material.Left))
+	assert.Equal(t, 3, len(material.Right))
+	assert.Equal(t, 2400, material.Units())
+
+	// 左側は問題の原価
+	assert.Equal(t, Item{Type: totalcosting.First, Unit: 400, Progress: 0.5, Conversion: 400, Price: 200, Cost: 80000}, material.Left[0])
+
+	// 度外視法・両者負担
+	assert.True(t, material.Right[0].IsBearer())
+	assert.True(t, material.Right[1].IsNormalLoss())
+	assert.False(t, material.Right[1].IsBearer())
+	assert.True(t, material.Right[2].IsBearer())
+	assert.Equal(t, 100000.0, material.Right[2].Cost)
+
+	conversion := diagrams[1]
+	assert.Equal(t, "原価要素2", conversion.Name)
+	assert.Equal(t, "平均的投入 / 平均法 / 度外視法", conversion.Method)
+	assert.Equal(t, 40, conversion.Right[1].Conversion)
+}
+
+func TestNewWithoutNormalLoss(t *testing.T) {
+	box := newDefectBox()
+	box.Master = []totalcosting.Element{
+		{Type: totalcosting.Input, Unit: 1000},
+		{Type: totalcosting.Output, Unit: 800},
+		{Type: totalcosting.Last, Unit: 200, Progress: 0.5},
+	}
+	box.Run()
+
+	// 正常仕損がなければ負担する要素もない
+	for _, d := range New(box) {
+		for _, it := range d.Right {
+			assert.False(t, it.IsBearer())
+		}
+	}
+}
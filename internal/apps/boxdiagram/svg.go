@@ -0,0 +1,183 @@
+package boxdiagram
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// SVGのレイアウト
+const (
+	svgMargin     = 20  // 余白
+	svgSideWidth  = 320 // 左右それぞれの幅
+	svgHeader     = 52  // 原価要素名と計算方法の高さ
+	svgBoxHeight  = 360 // 数量に比例させる高さ
+	svgLineHeight = 16  // 1行の高さ
+	svgPadding    = 8   // 要素の上下の余白
+	svgGap        = 40  // 原価要素の間隔
+	svgLegend     = 28  // 凡例の高さ
+)
+
+// 要素の塗り
+const (
+	fillLeft       = "#eef4fb"
+	fillRight      = "#f7f7f7"
+	fillBearer     = "#fff2cc"
+	fillNormalLoss = "#fde2e2"
+)
+
+// SVG is Run済みのBoxの原価要素ごとのBox図をSVGで返す
+// 高さは数量に比例させ, 正常仕損費を負担する要素を強調する
+func SVG(box totalcosting.Box) string {
+	var body strings.Builder
+
+	y := svgMargin
+	for _, d := range New(box) {
+		y += writeDiagram(&body, d, y) + svgGap
+	}
+
+	// 凡例
+	legend := y - svgGap + svgMargin
+	fmt.Fprintf(&body, `<rect x="%d" y="%d" width="14" height="14" fill="%s" stroke="#d6a400"/>`, svgMargin, legend, fillBearer)
+	fmt.Fprintf(&body, `<text x="%d" y="%d">正常仕損費を負担する要素</text>`, svgMargin+20, legend+12)
+	fmt.Fprintf(&body, `<rect x="%d" y="%d" width="14" height="14" fill="%s" stroke="#c0392b" stroke-dasharray="4 2"/>`, svgMargin+220, legend, fillNormalLoss)
+	fmt.Fprintf(&body, `<text x="%d" y="%d">正常仕損・正常減損</text>`, svgMargin+240, legend+12)
+
+	width := svgMargin*2 + svgSideWidth*2
+	height := legend + svgLegend
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	sb.WriteString(body.String())
+	sb.WriteString("\n</svg>\n")
+
+	return sb.String()
+}
+
+// WriteSVG is Run済みのBoxのBox図をSVGで書き出す
+func WriteSVG(w io.Writer, box totalcosting.Box) error {
+	_, err := io.WriteString(w, SVG(box))
+
+	return err
+}
+
+// writeDiagram is 原価要素1つのBox図をtopから書き, 高さを返す
+func writeDiagram(sb *strings.Builder, d Diagram, top int) int {
+	sb.WriteString(`<g class="cost">` + "\n")
+	fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="16" font-weight="bold">%s</text>`+"\n", svgMargin, top+16, html.EscapeString(d.Name))
+	fmt.Fprintf(sb, `<text x="%d" y="%d">%s 単価 %s</text>`+"\n", svgMargin, top+36, html.EscapeString(d.Method), costreport.FormatPrice(d.Price))
+
+	boxTop := top + svgHeader
+	left := heights(d.Left, d.Units())
+	right := heights(d.Right, d.Units())
+	height := fill(left, right)
+
+	x := svgMargin
+	y := boxTop
+	for i, it := range d.Left {
+		writeItem(sb, it, x, y, left[i], fillLeft)
+		y += left[i]
+	}
+
+	x = svgMargin + svgSideWidth
+	y = boxTop
+	for i, it := range d.Right {
+		writeItem(sb, it, x, y, right[i], fillRight)
+		y += right[i]
+	}
+
+	// Box図の外枠
+	fmt.Fprintf(sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="#333" stroke-width="2"/>`+"\n",
+		svgMargin, boxTop, svgSideWidth*2, height)
+	sb.WriteString("</g>\n")
+
+	return svgHeader + height
+}
+
+// writeItem is 要素1つを描く
+func writeItem(sb *strings.Builder, it Item, x int, y int, height int, fillColor string) {
+	class := "element"
+	stroke := `stroke="#333"`
+
+	switch {
+	case it.IsNormalLoss():
+		class += " normal-loss"
+		fillColor = fillNormalLoss
+		stroke = `stroke="#c0392b" stroke-dasharray="4 2"`
+	case it.IsBearer():
+		class += " bearer"
+		fillColor = fillBearer
+		stroke = `stroke="#d6a400"`
+	}
+
+	fmt.Fprintf(sb, `<g class="%s" data-type="%s">`, class, it.Type)
+	fmt.Fprintf(sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" %s/>`, x, y, svgSideWidth, height, fillColor, stroke)
+
+	for i, line := range it.Lines() {
+		weight := ""
+		if i == 0 {
+			weight = ` font-weight="bold"`
+		}
+		fmt.Fprintf(sb, `<text x="%d" y="%d"%s>%s</text>`, x+8, y+svgPadding+svgLineHeight*(i+1)-4, weight, html.EscapeString(line))
+	}
+
+	sb.WriteString("</g>\n")
+}
+
+// heights is 数量に比例した要素の高さを返す
+// 文字が収まるように最低の高さを確保する
+func heights(items []Item, total int) []int {
+	result := make([]int, len(items))
+
+	for i, it := range items {
+		minimum := svgPadding*2 + svgLineHeight*len(it.Lines())
+
+		h := minimum
+		if total > 0 {
+			h = svgBoxHeight * it.Unit / total
+		}
+		if h < minimum {
+			h = minimum
+		}
+
+		result[i] = h
+	}
+
+	return result
+}
+
+// fill is 低い方の側の最後の要素を伸ばして左右の高さを揃え, 高さを返す
+func fill(left []int, right []int) int {
+	l, r := sum(left), sum(right)
+
+	switch {
+	case l < r && len(left) > 0:
+		left[len(left)-1] += r - l
+		return r
+	case r < l && len(right) > 0:
+		right[len(right)-1] += l - r
+		return l
+	}
+
+	if l > r {
+		return l
+	}
+
+	return r
+}
+
+// sum is 合計を返す
+func sum(values []int) int {
+	total := 0
+
+	for _, v := range values {
+		total += v
+	}
+
+	return total
+}
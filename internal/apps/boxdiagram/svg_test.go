@@ -0,0 +1,75 @@
+package boxdiagram
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSVG(t *testing.T) {
+	box := newDefectBox()
+	box.Run()
+
+	svg := SVG(box)
+
+	// 整形式のXML
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			break
+		}
+	}
+
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Equal(t, 2, strings.Count(svg, `<g class="cost">`))
+	assert.Equal(t, 4, strings.Count(svg, `class="element bearer"`))
+	assert.Equal(t, 2, strings.Count(svg, `class="element normal-loss"`))
+	assert.Contains(t, svg, `<text x="28" y="92" font-weight="bold">月初仕掛品</text>`)
+	assert.Contains(t, svg, "原価 100,000 (@200)")
+	assert.Contains(t, svg, "負担量 500")
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteSVG(&buf, box))
+	assert.Equal(t, svg, buf.String())
+}
+
+func TestSVGWithoutNormalLoss(t *testing.T) {
+	box := newDefectBox()
+	box.Master = []totalcosting.Element{
+		{Type: totalcosting.Input, Unit: 1000},
+		{Type: totalcosting.Output, Unit: 800},
+		{Type: totalcosting.Last, Unit: 200, Progress: 0.5},
+	}
+	box.Run()
+
+	// 正常仕損がなければ正常仕損費を負担する要素として塗らない
+	svg := SVG(box)
+	assert.NotContains(t, svg, `class="element bearer"`)
+	assert.NotContains(t, svg, "負担量")
+}
+
+func TestHeights(t *testing.T) {
+	box := newDefectBox()
+	box.Run()
+	d := New(box)[0]
+
+	left := heights(d.Left, d.Units())
+	right := heights(d.Right, d.Units())
+
+	// 当月投入は数量に比例し, 正常仕損は最低の高さ
+	assert.Equal(t, svgBoxHeight*2000/2400, left[1])
+	assert.Equal(t, svgPadding*2+svgLineHeight*5, right[1])
+
+	height := fill(left, right)
+	assert.Equal(t, height, sum(left))
+	assert.Equal(t, height, sum(right))
+}
@@ -0,0 +1,178 @@
+package boxdiagram
+
+import (
+	"io"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Border is 枠線に使う文字
+type Border struct {
+	Horizontal   string
+	Vertical     string
+	TopLeft      string
+	TopMiddle    string
+	TopRight     string
+	Left         string // 左端の区切り
+	Middle       string // 左右とも区切り
+	MiddleLeft   string // 左側だけ区切り
+	MiddleRight  string // 右側だけ区切り
+	Right        string // 右端の区切り
+	BottomLeft   string
+	BottomMiddle string
+	BottomRight  string
+}
+
+// Unicode is 罫線素片の枠線
+var Unicode = Border{"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "├", "┤", "└", "┴", "┘"}
+
+// ASCII is ASCII文字だけの枠線
+var ASCII = Border{"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+", "+", "+"}
+
+// textMinWidth is 左右それぞれの最小の幅
+const textMinWidth = 24
+
+// Text is Run済みのBoxの原価要素ごとのBox図を枠線で描いたテキストで返す
+// 全角文字は幅2として揃える
+func Text(box totalcosting.Box, border Border) string {
+	var sb strings.Builder
+
+	for i, d := range New(box) {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		writeText(&sb, d, border)
+	}
+
+	return sb.String()
+}
+
+// WriteText is Run済みのBoxのBox図を罫線素片で書き出す
+func WriteText(w io.Writer, box totalcosting.Box) error {
+	_, err := io.WriteString(w, Text(box, Unicode))
+
+	return err
+}
+
+// separator is 要素の区切りを表す行
+const separator = "\x00"
+
+// writeText is 原価要素1つのBox図を書く
+func writeText(sb *strings.Builder, d Diagram, b Border) {
+	sb.WriteString(d.Name + "  " + d.Method + "  単価 " + costreport.FormatPrice(d.Price) + "\n")
+
+	left := column(d.Left)
+	right := column(d.Right)
+
+	width := textMinWidth
+	for _, line := range append(append([]string{}, left...), right...) {
+		if w := DisplayWidth(line) + 2; w > width {
+			width = w
+		}
+	}
+
+	// 低い方の側は最後の要素を伸ばす
+	for len(left) < len(right) {
+		left = append(left, "")
+	}
+	for len(right) < len(left) {
+		right = append(right, "")
+	}
+
+	rule := strings.Repeat(b.Horizontal, width)
+	sb.WriteString(b.TopLeft + rule + b.TopMiddle + rule + b.TopRight + "\n")
+
+	for k := range left {
+		l, r := left[k] == separator, right[k] == separator
+
+		start, middle, end := b.Vertical, b.Vertical, b.Vertical
+		if l {
+			start = b.Left
+			middle = b.MiddleLeft
+		}
+		if r {
+			end = b.Right
+			middle = b.MiddleRight
+		}
+		if l && r {
+			middle = b.Middle
+		}
+
+		sb.WriteString(start + cell(left[k], width, rule) + middle + cell(right[k], width, rule) + end + "\n")
+	}
+
+	sb.WriteString(b.BottomLeft + rule + b.BottomMiddle + rule + b.BottomRight + "\n")
+}
+
+// column is 片側の要素を行に並べる
+// 要素の間には区切りの行を入れる
+func column(items []Item) []string {
+	var result []string
+
+	for i, it := range items {
+		if i > 0 {
+			result = append(result, separator)
+		}
+		for k, line := range it.Lines() {
+			if k > 0 {
+				line = "  " + line
+			}
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+// cell is 1行をwidthの幅にする
+func cell(line string, width int, rule string) string {
+	if line == separator {
+		return rule
+	}
+
+	return PadRight(" "+line, width)
+}
+
+// PadRight is 表示幅がwidthになるまで右を空白で埋める
+func PadRight(s string, width int) string {
+	w := DisplayWidth(s)
+	if w >= width {
+		return s
+	}
+
+	return s + strings.Repeat(" ", width-w)
+}
+
+// DisplayWidth is 端末での表示幅を返す
+// 東アジアの全角文字は幅2, それ以外は幅1として数える
+func DisplayWidth(s string) int {
+	width := 0
+
+	for _, r := range s {
+		if isWide(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+
+	return width
+}
+
+// isWide is 全角で表示される文字か判定
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) || // ハングル字母
+		(r >= 0x2e80 && r <= 0x303e) || // CJK部首, 記号
+		(r >= 0x3041 && r <= 0x33ff) || // ひらがな, カタカナ, CJK互換
+		(r >= 0x3400 && r <= 0x4dbf) || // CJK統合漢字拡張A
+		(r >= 0x4e00 && r <= 0x9fff) || // CJK統合漢字
+		(r >= 0xa000 && r <= 0xa4cf) || // イ文字
+		(r >= 0xac00 && r <= 0xd7a3) || // ハングル
+		(r >= 0xf900 && r <= 0xfaff) || // CJK互換漢字
+		(r >= 0xfe30 && r <= 0xfe4f) || // CJK互換形
+		(r >= 0xff00 && r <= 0xff60) || // 全角英数, 記号
+		(r >= 0xffe0 && r <= 0xffe6) || // 全角記号
+		(r >= 0x20000 && r <= 0x3fffd) // CJK統合漢字拡張B以降
+}
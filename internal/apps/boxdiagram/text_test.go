@@ -0,0 +1,72 @@
+package boxdiagram
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	box := newDefectBox()
+	box.Costs = box.Costs[:1]
+	box.Run()
+
+	expected := `直接材料費  定点投入(投入点0) / 平均法 / 度外視法  単価 200
++-------------------------------+-------------------------------+
+| 月初仕掛品                    | 完成品 (正常仕損費を負担)     |
+|   数量 400 (進捗度 0.5)       |   数量 1,800                  |
+|   換算量 400                  |   換算量 1,800                |
+|   原価 80,000 (@200)          |   原価 360,000 (@200)         |
++-------------------------------+   負担量 1,800                |
+| 当月投入                      +-------------------------------+
+|   数量 2,000                  | 正常仕損                      |
+|   換算量 2,000                |   数量 100 (進捗度 0.4)       |
+|   原価 380,000 (@190)         |   換算量 100                  |
+|                               |   原価 19,167 (@191.67)       |
+|                               |   負担する要素に配分          |
+|                               +-------------------------------+
+|                               | 月末仕掛品 (正常仕損費を負担) |
+|                               |   数量 500 (進捗度 0.6)       |
+|                               |   換算量 500                  |
+|                               |   原価 100,000 (@200)         |
+|                               |   負担量 500                  |
++-------------------------------+-------------------------------+
+`
+	assert.Equal(t, expected, Text(box, ASCII))
+
+	// 罫線の行はすべて同じ表示幅
+	var buf bytes.Buffer
+	assert.NoError(t, WriteText(&buf, box))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")[1:]
+	for _, line := range lines {
+		assert.Equal(t, DisplayWidth(lines[0]), DisplayWidth(line), line)
+	}
+	assert.True(t, strings.HasPrefix(lines[0], "┌"))
+	assert.Contains(t, buf.String(), "┤   負担量 1,800")
+}
+
+func TestDisplayWidth(t *testing.T) {
+	testCases := []struct {
+		S      string
+		Result int
+	}{
+		{"abc", 3},
+		{"完成品", 6},
+		{"度外視法・両者負担", 18},
+		{"ＡＢ", 4},
+		{"원가", 4},
+		{"─│", 2},
+		{"¥1,000", 6},
+	}
+
+	for _, testCase := range testCases {
+		result := DisplayWidth(testCase.S)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d", testCase, result)
+		}
+	}
+
+	assert.Equal(t, "完成品  |", PadRight("完成品", 8)+"|")
+}
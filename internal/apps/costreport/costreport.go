@@ -0,0 +1,201 @@
+package costreport
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Variance is 売上原価に賦課する原価差異
+// Amountは予定(標準)消費額 - 実際消費額なので、負なら不利差異
+type Variance struct {
+	Name   string
+	Amount float64
+}
+
+// Input is 報告書の作成に必要な金額
+// 材料費, 労務費, 経費は製品に負担させた額(予定価格を使っていれば予定消費額)
+type Input struct {
+	Material     float64 // 材料費
+	Labor        float64 // 労務費
+	Expense      float64 // 経費
+	Boxes        []totalcosting.Box
+	Sequential   bool    // Boxesが工程順に並んだ連続工程
+	FirstProduct float64 // 期首製品棚卸高
+	LastProduct  float64 // 期末製品棚卸高
+	Variances    []Variance
+}
+
+// Line is 報告書の1行
+type Line struct {
+	Label  string
+	Amount float64
+}
+
+// Report is 製造原価報告書と損益計算書の売上原価の区分
+type Report struct {
+	Material           float64 // 材料費
+	Labor              float64 // 労務費
+	Expense            float64 // 経費
+	TotalManufacturing float64 // 当期総製造費用
+	FirstWIP           float64 // 期首仕掛品棚卸高
+	LastWIP            float64 // 期末仕掛品棚卸高
+	CompletedCost      float64 // 当期製品製造原価
+	BoxProductCost     float64 // Boxで計算した完成品原価の合計
+	FirstProduct       float64 // 期首製品棚卸高
+	LastProduct        float64 // 期末製品棚卸高
+	Available          float64 // 期首製品棚卸高 + 当期製品製造原価
+	BeforeVariance     float64 // 原価差異賦課前の売上原価
+	Variances          []Variance
+	COGS               float64 // 売上原価
+}
+
+// New is 製造原価報告書を作成する
+// BoxesはRun済みであること
+// 連続工程では前工程の完成品原価が次工程の前工程費に振り替えられるので, 最終工程の完成品原価だけを合計する
+func New(in Input) Report {
+	var r Report
+
+	r.Material = in.Material
+	r.Labor = in.Labor
+	r.Expense = in.Expense
+	r.TotalManufacturing = r.Material + r.Labor + r.Expense
+
+	for _, b := range in.Boxes {
+		for _, c := range b.Costs {
+			r.FirstWIP += c.FirstCost
+		}
+		r.LastWIP += b.EOTMTotalCost
+		r.BoxProductCost += b.ProductTotalCost
+	}
+
+	if in.Sequential && len(in.Boxes) > 0 {
+		r.BoxProductCost = in.Boxes[len(in.Boxes)-1].ProductTotalCost
+	}
+
+	r.CompletedCost = r.TotalManufacturing + r.FirstWIP - r.LastWIP
+
+	r.FirstProduct = in.FirstProduct
+	r.LastProduct = in.LastProduct
+	r.Available = r.FirstProduct + r.CompletedCost
+	r.BeforeVariance = r.Available - r.LastProduct
+
+	r.Variances = in.Variances
+	r.COGS = r.BeforeVariance
+	for _, v := range r.Variances {
+		// 不利差異は売上原価に加算, 有利差異は減算
+		r.COGS -= v.Amount
+	}
+
+	return r
+}
+
+// Difference is 報告書の当期製品製造原価とBoxの完成品原価の差額を返す
+// 0でなければ費目別計算とBoxの投入原価が一致していない
+func (r Report) Difference() float64 {
+	return r.CompletedCost - r.BoxProductCost
+}
+
+// IsReconciled is 当期製品製造原価とBoxの完成品原価が一致しているか判定
+// 1円未満の差は端数として許容する
+func (r Report) IsReconciled() bool {
+	return math.Abs(r.Difference()) < 1.0
+}
+
+// ManufacturingLines is 製造原価報告書の行を返す
+func (r Report) ManufacturingLines() []Line {
+	return []Line{
+		{"材料費", r.Material},
+		{"労務費", r.Labor},
+		{"経費", r.Expense},
+		{"当期総製造費用", r.TotalManufacturing},
+		{"期首仕掛品棚卸高", r.FirstWIP},
+		{"合計", r.TotalManufacturing + r.FirstWIP},
+		{"期末仕掛品棚卸高", r.LastWIP},
+		{"当期製品製造原価", r.CompletedCost},
+	}
+}
+
+// COGSLines is 損益計算書の売上原価の区分の行を返す
+func (r Report) COGSLines() []Line {
+	lines := []Line{
+		{"期首製品棚卸高", r.FirstProduct},
+		{"当期製品製造原価", r.CompletedCost},
+		{"合計", r.Available},
+		{"期末製品棚卸高", r.LastProduct},
+	}
+
+	if len(r.Variances) == 0 {
+		return append(lines, Line{"売上原価", r.COGS})
+	}
+
+	lines = append(lines, Line{"差引", r.BeforeVariance})
+	for _, v := range r.Variances {
+		// 報告書では売上原価への加算額で表示する
+		lines = append(lines, Line{v.Name, -v.Amount})
+	}
+
+	return append(lines, Line{"売上原価", r.COGS})
+}
+
+// String is 製造原価報告書と売上原価の区分をテキストで返す
+func (r Report) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("製造原価報告書\n")
+	writeLines(&sb, r.ManufacturingLines())
+	sb.WriteString("\n売上原価\n")
+	writeLines(&sb, r.COGSLines())
+
+	return sb.String()
+}
+
+// writeLines is 行を金額右寄せで書き出す
+func writeLines(sb *strings.Builder, lines []Line) {
+	for _, l := range lines {
+		fmt.Fprintf(sb, "  %s\t%15s\n", l.Label, FormatYen(l.Amount))
+	}
+}
+
+// FormatYen is 金額を3桁区切りの円単位の文字列にする
+// 1円未満は四捨五入する
+func FormatYen(amount float64) string {
+	n := int64(math.Round(amount))
+
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	s := fmt.Sprintf("%d", n)
+	var sb strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+
+	return sign + sb.String()
+}
+
+// FormatPrice is 単価を小数点以下2桁までで表示する
+// 負の値は絶対値を表示して符号を1つだけ付ける
+func FormatPrice(v float64) string {
+	rounded := math.Round(v*100) / 100
+	if rounded == math.Trunc(rounded) {
+		return FormatYen(rounded)
+	}
+
+	sign := ""
+	if rounded < 0 {
+		sign = "-"
+		rounded = -rounded
+	}
+
+	return sign + FormatYen(math.Trunc(rounded)) + strconv.FormatFloat(rounded-math.Trunc(rounded), 'f', 2, 64)[1:]
+}
@@ -0,0 +1,147 @@
+package costreport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	report := New(Input{
+		Material:     717600,
+		Labor:        600000,
+		Expense:      372360,
+		Boxes:        []totalcosting.Box{totalcostingtest.Solved(totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting))},
+		FirstProduct: 200000,
+		LastProduct:  300000,
+		Variances: []Variance{
+			{Name: "材料消費価格差異", Amount: -900},
+			{Name: "賃率差異", Amount: 16000},
+		},
+	})
+
+	assert.Equal(t, 1689960.0, report.TotalManufacturing)
+	assert.Equal(t, 368040.0, report.FirstWIP)
+	assert.Equal(t, 186000.0, report.LastWIP)
+	assert.Equal(t, 1872000.0, report.CompletedCost)
+	assert.True(t, report.IsReconciled())
+	assert.Equal(t, 2072000.0, report.Available)
+	assert.Equal(t, 1772000.0, report.BeforeVariance)
+	assert.Equal(t, 1756900.0, report.COGS)
+}
+
+func TestNewNotReconciled(t *testing.T) {
+	report := New(Input{
+		Material: 700000,
+		Labor:    600000,
+		Expense:  372360,
+		Boxes:    []totalcosting.Box{totalcostingtest.Solved(totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting))},
+	})
+
+	assert.False(t, report.IsReconciled())
+	assert.Equal(t, -17600.0, report.Difference())
+}
+
+func TestNewSequential(t *testing.T) {
+	first := totalcostingtest.Solved(totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting))
+
+	// 第1工程の完成品原価を前工程費として投入する
+	second := totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.First, Unit: 0},
+			{Type: totalcosting.Input, Unit: 1440},
+			{Type: totalcosting.Output, Unit: 1200},
+			{Type: totalcosting.Last, Unit: 240, Progress: 0.5},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "前工程費", CMethod: totalcosting.AVG, DMethod: totalcosting.NonNeglecting, InputCost: first.ProductTotalCost},
+			{Name: "加工費", InputOnAvg: true, CMethod: totalcosting.AVG, DMethod: totalcosting.NonNeglecting, InputCost: 132000},
+		},
+	}
+	second.Run()
+
+	in := Input{
+		Material: 717600,
+		Labor:    660000,
+		Expense:  444360,
+		Boxes:    []totalcosting.Box{first, second},
+	}
+
+	// 並列工程として合計すると前工程費を二重に数える
+	report := New(in)
+	assert.False(t, report.IsReconciled())
+
+	in.Sequential = true
+	report = New(in)
+	assert.Equal(t, 510000.0, report.LastWIP)
+	assert.Equal(t, 1680000.0, report.CompletedCost)
+	assert.Equal(t, 1680000.0, report.BoxProductCost)
+	assert.True(t, report.IsReconciled())
+}
+
+func TestCOGSLines(t *testing.T) {
+	report := New(Input{FirstProduct: 100, LastProduct: 50})
+	lines := report.COGSLines()
+	assert.Equal(t, 5, len(lines))
+	assert.Equal(t, Line{"売上原価", 50}, lines[4])
+
+	report = New(Input{Variances: []Variance{{Name: "賃率差異", Amount: -300}}})
+	lines = report.COGSLines()
+	assert.Equal(t, Line{"賃率差異", 300}, lines[5])
+	assert.Equal(t, Line{"売上原価", 300}, lines[6])
+}
+
+func TestString(t *testing.T) {
+	report := New(Input{Material: 1234567})
+	s := report.String()
+
+	assert.True(t, strings.HasPrefix(s, "製造原価報告書\n"))
+	assert.Contains(t, s, "1,234,567")
+}
+
+func TestFormatYen(t *testing.T) {
+	testCases := []struct {
+		Amount float64
+		Result string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{1872000, "1,872,000"},
+		{-900, "-900"},
+		{1234.5, "1,235"},
+	}
+
+	for _, testCase := range testCases {
+		result := FormatYen(testCase.Amount)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+		}
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	testCases := []struct {
+		V      float64
+		Result string
+	}{
+		{1300, "1,300"},
+		{682.857142, "682.86"},
+		{1234.5, "1,234.50"},
+		{-1.5, "-1.50"},
+		{-0.25, "-0.25"},
+		{-1234.567, "-1,234.57"},
+		{-1300, "-1,300"},
+		{-0.001, "0"},
+	}
+
+	for _, testCase := range testCases {
+		result := FormatPrice(testCase.V)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Mapping is CSVの見出しと項目の対応
+// 空文字の項目は読み込まない(必須項目は空にできない)
+type Mapping struct {
+	// 物量のCSV
+	Type     string // 種別(必須)
+	Unit     string // 数量(必須)
+	Progress string // 加工進捗度
+
+	// 原価のCSV
+	Name        string // 原価要素
+	InputTiming string // 投入点(uniformなら平均的投入)(必須)
+	CMethod     string // 月末仕掛品の計算方法(必須)
+	DMethod     string // 正常仕損の計算方法
+	FirstCost   string // 月初仕掛品原価
+	InputCost   string // 当月投入原価(必須)
+}
+
+// DefaultMapping is 標準の見出しを返す
+func DefaultMapping() Mapping {
+	return Mapping{
+		Type:        "type",
+		Unit:        "unit",
+		Progress:    "progress",
+		Name:        "name",
+		InputTiming: "input_timing",
+		CMethod:     "calculation_method",
+		DMethod:     "defective_product_method",
+		FirstCost:   "first_cost",
+		InputCost:   "input_cost",
+	}
+}
+
+// header is 項目の見出しを返す
+func (m Mapping) header(field string) string {
+	headers := map[string]string{
+		"type":                     m.Type,
+		"unit":                     m.Unit,
+		"progress":                 m.Progress,
+		"name":                     m.Name,
+		"input_timing":             m.InputTiming,
+		"calculation_method":       m.CMethod,
+		"defective_product_method": m.DMethod,
+		"first_cost":               m.FirstCost,
+		"input_cost":               m.InputCost,
+	}
+
+	return headers[field]
+}
+
+// uniformValues is 平均的投入を表す投入点の値
+var uniformValues = []string{"uniform", "平均", "平均的", "平均的投入"}
+
+// RowError is CSVの行と列を示すエラー
+type RowError struct {
+	File   string // ファイル名
+	Row    int    // 行番号(見出しが1行目)
+	Column int    // 列番号(0始まり), 行全体なら-1
+	Field  string // 見出し
+	Err    error
+}
+
+// Error is エラーの位置と内容を返す
+func (e RowError) Error() string {
+	var sb strings.Builder
+
+	if e.File != "" {
+		sb.WriteString(e.File + ": ")
+	}
+	fmt.Fprintf(&sb, "row %d", e.Row)
+	switch {
+	case e.Column >= 0 && e.Field != "":
+		fmt.Fprintf(&sb, ", column %d (%s)", e.Column+1, e.Field)
+	case e.Column >= 0:
+		fmt.Fprintf(&sb, ", column %d", e.Column+1)
+	case e.Field != "":
+		fmt.Fprintf(&sb, ", column %s", e.Field)
+	}
+	sb.WriteString(": " + e.Err.Error())
+
+	return sb.String()
+}
+
+// Unwrap is 元のエラーを返す
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportError is 読み込みで見つかったエラーの一覧
+type ImportError []RowError
+
+// Error is エラーを1行ずつまとめた文字列を返す
+func (e ImportError) Error() string {
+	messages := make([]string, len(e))
+
+	for i, r := range e {
+		messages[i] = r.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Importer is CSVからBoxを作る
+type Importer struct {
+	Mapping Mapping
+}
+
+// New is 標準の見出しを使うImporterを返す
+func New() Importer {
+	return Importer{Mapping: DefaultMapping()}
+}
+
+// ImportFiles is 物量と原価のCSVファイルからBoxを作る
+func (im Importer) ImportFiles(masterPath string, costsPath string) (totalcosting.Box, error) {
+	master, err := os.Open(masterPath)
+	if err != nil {
+		return totalcosting.Box{}, err
+	}
+	defer master.Close()
+
+	costs, err := os.Open(costsPath)
+	if err != nil {
+		return totalcosting.Box{}, err
+	}
+	defer costs.Close()
+
+	return im.importNamed(master, masterPath, costs, costsPath)
+}
+
+// Import is 物量と原価のCSVからBoxを作る
+// エラーがあればImportErrorを返す
+func (im Importer) Import(master io.Reader, costs io.Reader) (totalcosting.Box, error) {
+	return im.importNamed(master, "master", costs, "costs")
+}
+
+// importNamed is ファイル名をエラーに付けてBoxを作る
+// CSVとして読めないときもcsv.ParseErrorにファイル名を付ける
+func (im Importer) importNamed(master io.Reader, masterName string, costs io.Reader, costsName string) (totalcosting.Box, error) {
+	var box totalcosting.Box
+	var errs ImportError
+
+	records, err := readAll(master)
+	if err != nil {
+		return box, fmt.Errorf("%s: %w", masterName, err)
+	}
+	box.Master, errs = im.MasterFromRecords(records)
+	errs = named(errs, masterName)
+
+	records, err = readAll(costs)
+	if err != nil {
+		return box, fmt.Errorf("%s: %w", costsName, err)
+	}
+	var costErrs ImportError
+	box.Costs, costErrs = im.CostsFromRecords(records)
+	errs = append(errs, named(costErrs, costsName)...)
+
+	if len(errs) > 0 {
+		return box, errs
+	}
+
+	return box, nil
+}
+
+// MasterFromRecords is 見出し付きの行から物量を読み込む
+func (im Importer) MasterFromRecords(records [][]string) ([]totalcosting.Element, ImportError) {
+	required := []string{"type", "unit"}
+	columns, errs := findColumns(records, im.Mapping, []string{"type", "unit", "progress"}, required)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var elements []totalcosting.Element
+	for i, record := range records[1:] {
+		r := row{record: record, number: i + 2, columns: columns, required: required, mapping: im.Mapping}
+		if r.isBlank() {
+			continue
+		}
+
+		var e totalcosting.Element
+		r.parse("type", func(v string) error { return e.Type.UnmarshalText([]byte(v)) }, &errs)
+		r.parse("unit", func(v string) (err error) { e.Unit, err = strconv.Atoi(v); return }, &errs)
+		r.parse("progress", func(v string) (err error) { e.Progress, err = ParseRatio(v); return }, &errs)
+		elements = append(elements, e)
+	}
+
+	return elements, errs
+}
+
+// CostsFromRecords is 見出し付きの行から原価要素を読み込む
+func (im Importer) CostsFromRecords(records [][]string) ([]totalcosting.Cost, ImportError) {
+	required := []string{"input_timing", "calculation_method", "input_cost"}
+	columns, errs := findColumns(records, im.Mapping,
+		[]string{"name", "input_timing", "calculation_method", "defective_product_method", "first_cost", "input_cost"},
+		required)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var costs []totalcosting.Cost
+	for i, record := range records[1:] {
+		r := row{record: record, number: i + 2, columns: columns, required: required, mapping: im.Mapping}
+		if r.isBlank() {
+			continue
+		}
+
+		var c totalcosting.Cost
+		r.parse("name", func(v string) error { c.Name = v; return nil }, &errs)
+		r.parse("input_timing", func(v string) (err error) {
+			c.InputOnAvg, c.InputTiming, err = ParseInputTiming(v)
+			return
+		}, &errs)
+		r.parse("calculation_method", func(v string) error { return c.CMethod.UnmarshalText([]byte(v)) }, &errs)
+		r.parse("defective_product_method", func(v string) error { return c.DMethod.UnmarshalText([]byte(v)) }, &errs)
+		r.parse("first_cost", func(v string) (err error) { c.FirstCost, err = ParseAmount(v); return }, &errs)
+		r.parse("input_cost", func(v string) (err error) { c.InputCost, err = ParseAmount(v); return }, &errs)
+		costs = append(costs, c)
+	}
+
+	return costs, errs
+}
+
+// ParseRatio is 進捗度や投入点を読む
+// 0.6のような小数と60%のような百分率を受け付ける
+func ParseRatio(v string) (float64, error) {
+	if strings.HasSuffix(v, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(v, "%")), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ratio %q", v)
+		}
+		return percent / 100, nil
+	}
+
+	ratio, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ratio %q", v)
+	}
+
+	return ratio, nil
+}
+
+// ParseInputTiming is 投入点を読む
+// uniformなどなら平均的投入
+func ParseInputTiming(v string) (bool, float64, error) {
+	for _, u := range uniformValues {
+		if strings.EqualFold(v, u) {
+			return true, 0, nil
+		}
+	}
+
+	timing, err := ParseRatio(v)
+
+	return false, timing, err
+}
+
+// ParseAmount is 金額を読む
+// 3桁区切りのカンマと円記号は無視する
+func ParseAmount(v string) (float64, error) {
+	cleaned := strings.NewReplacer(",", "", "¥", "", "￥", "", "円", "").Replace(v)
+
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+
+	return amount, nil
+}
+
+// row is 読み込み中の1行
+type row struct {
+	record   []string
+	number   int
+	columns  map[string]int
+	required []string // 空欄にできない項目
+	mapping  Mapping
+}
+
+// isBlank is 空行か判定
+func (r row) isBlank() bool {
+	for _, v := range r.record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parse is 項目の値を読み込む
+// 列がなければ何もしない
+// 空欄なら必須項目だけエラーにする
+func (r row) parse(field string, set func(v string) error, errs *ImportError) {
+	column, ok := r.columns[field]
+	if !ok {
+		return
+	}
+
+	v := ""
+	if column < len(r.record) {
+		v = strings.TrimSpace(r.record[column])
+	}
+	if v == "" {
+		if r.isRequired(field) {
+			*errs = append(*errs, RowError{Row: r.number, Column: column, Field: r.mapping.header(field), Err: errors.New("required value is blank")})
+		}
+		return
+	}
+
+	if err := set(v); err != nil {
+		*errs = append(*errs, RowError{Row: r.number, Column: column, Field: r.mapping.header(field), Err: trimPrefix(err)})
+	}
+}
+
+// isRequired is 空欄にできない項目か判定
+func (r row) isRequired(field string) bool {
+	for _, f := range r.required {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findColumns is 見出し行からfieldsの列番号を探す
+// 必須項目の列がなければエラー
+func findColumns(records [][]string, m Mapping, fields []string, required []string) (map[string]int, ImportError) {
+	if len(records) == 0 {
+		return nil, ImportError{{Row: 1, Column: -1, Err: errors.New("header row is missing")}}
+	}
+
+	columns := make(map[string]int)
+	for _, field := range fields {
+		header := m.header(field)
+		if header == "" {
+			continue
+		}
+
+		for i, h := range records[0] {
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")), header) {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	var errs ImportError
+	for _, field := range required {
+		if _, ok := columns[field]; !ok {
+			errs = append(errs, RowError{Row: 1, Column: -1, Field: m.header(field), Err: errors.New("required column is missing")})
+		}
+	}
+
+	return columns, errs
+}
+
+// readAll is CSVをすべて読む
+// 列数が行ごとに異なっても許容する
+func readAll(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	return reader.ReadAll()
+}
+
+// named is エラーにファイル名を付ける
+func named(errs ImportError, name string) ImportError {
+	for i := range errs {
+		errs[i].File = name
+	}
+
+	return errs
+}
+
+// trimPrefix is エラーからパッケージ名の接頭辞を取り除く
+func trimPrefix(err error) error {
+	message := strings.TrimPrefix(err.Error(), "totalcosting: ")
+	message = strings.TrimPrefix(message, "strconv.Atoi: ")
+
+	return errors.New(message)
+}
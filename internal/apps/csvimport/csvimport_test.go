@@ -0,0 +1,138 @@
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportFiles(t *testing.T) {
+	box, err := New().ImportFiles("testdata/master.csv", "testdata/costs.csv")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []totalcosting.Element{
+		{Type: totalcosting.First, Unit: 300, Progress: 0.6},
+		{Type: totalcosting.Input, Unit: 1380},
+		{Type: totalcosting.Output, Unit: 1440},
+		{Type: totalcosting.Last, Unit: 240, Progress: 0.3},
+	}, box.Master)
+
+	assert.Equal(t, 2, len(box.Costs))
+	assert.Equal(t, "直接材料費", box.Costs[0].Name)
+	assert.Equal(t, 206400.0, box.Costs[0].FirstCost)
+	assert.Equal(t, totalcosting.NonNeglecting, box.Costs[0].DMethod)
+	assert.True(t, box.Costs[1].InputOnAvg)
+
+	assert.NoError(t, box.Validate())
+	box.Run()
+	assert.Equal(t, 1300.0, box.ProductAvgCost)
+}
+
+func TestImportMapping(t *testing.T) {
+	master := "区分,数量,進捗度\n完成品,100,\n当月投入,100,\n"
+	costs := "原価要素,投入点,方法,当月\n加工費,平均的投入,FIFO,5000\n"
+
+	im := Importer{Mapping: Mapping{
+		Type:        "区分",
+		Unit:        "数量",
+		Progress:    "進捗度",
+		Name:        "原価要素",
+		InputTiming: "投入点",
+		CMethod:     "方法",
+		InputCost:   "当月",
+	}}
+
+	box, err := im.Import(strings.NewReader(master), strings.NewReader(costs))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(box.Master))
+	assert.Equal(t, totalcosting.FIFO, box.Costs[0].CMethod)
+	assert.True(t, box.Costs[0].InputOnAvg)
+	assert.Equal(t, 5000.0, box.Costs[0].InputCost)
+}
+
+func TestImportError(t *testing.T) {
+	master := "type,unit,progress\n完成品,100x,\n不明,100,\n月末仕掛品,10,abc\n,20,0.5\n当月投入\n"
+	costs := "name,calculation_method,input_cost\n"
+
+	_, err := New().Import(strings.NewReader(master), strings.NewReader(costs))
+	errs, ok := err.(ImportError)
+	assert.True(t, ok)
+
+	expected := []string{
+		`master: row 2, column 2 (unit): parsing "100x": invalid syntax`,
+		`master: row 3, column 1 (type): unknown element type "不明"`,
+		`master: row 4, column 3 (progress): invalid ratio "abc"`,
+		`master: row 5, column 1 (type): required value is blank`,
+		`master: row 6, column 2 (unit): required value is blank`,
+		`costs: row 1, column input_timing: required column is missing`,
+	}
+	assert.Equal(t, len(expected), len(errs))
+	for i, e := range errs {
+		assert.Equal(t, expected[i], e.Error())
+	}
+	assert.Equal(t, 1, errs[0].Column)
+}
+
+func TestImportParseError(t *testing.T) {
+	valid := "type,unit,progress\n完成品,100,\n"
+	broken := "type,unit,progress\n\"完成品,100,\n"
+
+	testCases := []struct {
+		Master string
+		Costs  string
+		Result string
+	}{
+		{broken, valid, "master: "},
+		{valid, broken, "costs: "},
+	}
+
+	for _, testCase := range testCases {
+		_, err := New().Import(strings.NewReader(testCase.Master), strings.NewReader(testCase.Costs))
+
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) || !strings.HasPrefix(err.Error(), testCase.Result) {
+			t.Errorf("Invalid result. testCase:%#v, actual:%v", testCase, err)
+		}
+	}
+}
+
+func TestParseRatio(t *testing.T) {
+	testCases := []struct {
+		V      string
+		Result float64
+	}{
+		{"0.6", 0.6},
+		{"60%", 0.6},
+		{"100 %", 1.0},
+	}
+
+	for _, testCase := range testCases {
+		result, err := ParseRatio(testCase.V)
+		if err != nil || result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%f", testCase, result)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		V      string
+		Result float64
+	}{
+		{"206400", 206400.0},
+		{"206,400", 206400.0},
+		{"¥1,000", 1000.0},
+		{"1,000円", 1000.0},
+	}
+
+	for _, testCase := range testCases {
+		result, err := ParseAmount(testCase.V)
+		if err != nil || result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%f", testCase, result)
+		}
+	}
+}
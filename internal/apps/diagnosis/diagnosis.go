@@ -0,0 +1,340 @@
+package diagnosis
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/answer"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// よくある誤り
+const (
+	AVGInsteadOfFIFO           = "avg_instead_of_fifo"            // 先入先出法を平均法で計算した
+	FIFOInsteadOfAVG           = "fifo_instead_of_avg"            // 平均法を先入先出法で計算した
+	NeglectingInsteadOfNon     = "neglecting_instead_of_non"      // 非度外視法を度外視法で計算した
+	NonInsteadOfNeglecting     = "non_instead_of_neglecting"      // 度外視法を非度外視法で計算した
+	WIPBearsBeforeInspection   = "wip_bears_before_inspection"    // 仕損の発生点に達していない月末仕掛品に負担させた
+	UniformInsteadOfPointInput = "uniform_instead_of_point_input" // 定点投入の材料を平均的投入とした
+	TruncatedUnits             = "truncated_units"                // 完成品換算量の端数を切り捨てた
+)
+
+// Variant is 誤った前提で解き直したBox
+type Variant struct {
+	Mistake     string           `json:"mistake"`
+	Cost        int              `json:"cost"` // 誤った原価要素の添字, すべての原価要素なら-1
+	Description string           `json:"description"`
+	Box         totalcosting.Box `json:"-"`
+}
+
+// Match is 解答の誤りを説明できる誤り
+type Match struct {
+	Mistake     string   `json:"mistake"`
+	Cost        int      `json:"cost"`
+	Description string   `json:"description"`
+	Explained   []string `json:"explained"` // 誤った前提なら一致する項目のキー
+	Complete    bool     `json:"complete"`  // 誤った項目をすべて説明できる
+}
+
+// Diagnosis is 採点結果と誤りの診断
+type Diagnosis struct {
+	Result  answer.Result `json:"result"`
+	Matches []Match       `json:"matches"` // 説明できる項目の多い順
+}
+
+// Diagnose is 解答を採点し, 誤った項目があればよくある誤りで解き直して一致するものを探す
+// boxはRun前の問題
+func Diagnose(box totalcosting.Box, a answer.Answer, tolerance float64) (Diagnosis, error) {
+	solved := box.Clone()
+	solved.Run()
+
+	result, err := answer.Check(solved, a, tolerance)
+	if err != nil {
+		return Diagnosis{}, err
+	}
+
+	d := Diagnosis{Result: result, Matches: []Match{}}
+
+	wrong := 0
+	for _, it := range result.Items {
+		if !it.Correct {
+			wrong++
+		}
+	}
+	if wrong == 0 {
+		return d, nil
+	}
+
+	for _, v := range Variants(box) {
+		r, err := answer.Check(v.Box, a, tolerance)
+		if err != nil {
+			// 誤った前提では存在しない項目がある
+			continue
+		}
+
+		m := Match{Mistake: v.Mistake, Cost: v.Cost, Description: v.Description, Explained: []string{}}
+		for k, it := range r.Items {
+			if it.Correct && !result.Items[k].Correct {
+				m.Explained = append(m.Explained, it.Key)
+			}
+		}
+		if len(m.Explained) == 0 {
+			continue
+		}
+
+		m.Complete = len(m.Explained) == wrong
+		d.Matches = append(d.Matches, m)
+	}
+
+	sort.SliceStable(d.Matches, func(i, j int) bool {
+		return len(d.Matches[i].Explained) > len(d.Matches[j].Explained)
+	})
+
+	return d, nil
+}
+
+// Variants is Run前の問題をよくある誤りの前提で解き直したBoxを返す
+// 正しい解き方と同じになる誤りは含めない
+// 原価要素ごとの誤りは1つずつと, 当てはまる原価要素が複数あればすべてに当てはめたものを作る
+func Variants(box totalcosting.Box) []Variant {
+	var variants []Variant
+
+	solved := box.Clone()
+	solved.Run()
+	add := func(v Variant) {
+		if !sameResult(solved, v.Box) {
+			variants = append(variants, v)
+		}
+	}
+
+	perCost := []struct {
+		Mistake string
+		Format  string
+		Apply   func(c *totalcosting.Cost) bool
+	}{
+		{AVGInsteadOfFIFO, "%sを先入先出法ではなく平均法で計算した", func(c *totalcosting.Cost) bool {
+			if c.CMethod != totalcosting.FIFO {
+				return false
+			}
+			c.CMethod = totalcosting.AVG
+			return true
+		}},
+		{FIFOInsteadOfAVG, "%sを平均法ではなく先入先出法で計算した", func(c *totalcosting.Cost) bool {
+			if c.CMethod != totalcosting.AVG {
+				return false
+			}
+			c.CMethod = totalcosting.FIFO
+			return true
+		}},
+		{NeglectingInsteadOfNon, "%sの正常仕損費を非度外視法ではなく度外視法で計算した", func(c *totalcosting.Cost) bool {
+			if c.DMethod != totalcosting.NonNeglecting || !hasNormalLoss(box) {
+				return false
+			}
+			c.DMethod = totalcosting.Neglecting
+			return true
+		}},
+		{NonInsteadOfNeglecting, "%sの正常仕損費を度外視法ではなく非度外視法で計算した", func(c *totalcosting.Cost) bool {
+			if c.DMethod != totalcosting.Neglecting || !hasNormalLoss(box) {
+				return false
+			}
+			c.DMethod = totalcosting.NonNeglecting
+			return true
+		}},
+		{UniformInsteadOfPointInput, "%sを定点投入ではなく平均的投入として計算した", func(c *totalcosting.Cost) bool {
+			if c.InputOnAvg {
+				return false
+			}
+			c.InputOnAvg = true
+			return true
+		}},
+	}
+
+	for _, m := range perCost {
+		all := box.Clone()
+		applied := 0
+
+		for i := range box.Costs {
+			v := box.Clone()
+			if !m.Apply(&v.Costs[i]) {
+				continue
+			}
+			m.Apply(&all.Costs[i])
+			applied++
+
+			v.Run()
+			add(Variant{m.Mistake, i, fmt.Sprintf(m.Format, box.CostName(i)), v})
+		}
+
+		if applied > 1 {
+			all.Run()
+			add(Variant{m.Mistake, -1, fmt.Sprintf(m.Format, "すべての原価要素"), all})
+		}
+	}
+
+	// 仕損の発生点に達していない月末仕掛品への負担
+	all := box.Clone()
+	all.Run()
+	applied := 0
+	for i := range box.Costs {
+		v := box.Clone()
+		v.Run()
+		if !bearLast(&v, i) {
+			continue
+		}
+		bearLast(&all, i)
+		applied++
+
+		add(Variant{WIPBearsBeforeInspection, i,
+			fmt.Sprintf("%sの正常仕損費を発生点に達していない月末仕掛品にも負担させた", box.CostName(i)), v})
+	}
+	if applied > 1 {
+		add(Variant{WIPBearsBeforeInspection, -1,
+			"すべての原価要素の正常仕損費を発生点に達していない月末仕掛品にも負担させた", all})
+	}
+
+	// 完成品換算量の端数の切り捨て
+	if v, ok := truncate(box); ok {
+		v.Run()
+		add(Variant{TruncatedUnits, -1, "完成品換算量の端数を四捨五入ではなく切り捨てた", v})
+	}
+
+	return variants
+}
+
+// bearLast is Run済みのBoxのi番目の原価要素で, 正常仕損費を負担していない月末仕掛品にも負担させて配分し直す
+// 正常仕損が1つで, 月末仕掛品が負担していないときだけ配分し直してtrueを返す
+func bearLast(box *totalcosting.Box, i int) bool {
+	c := &box.Costs[i]
+
+	loss, last := -1, totalcosting.Index(totalcosting.Last, c.Elements)
+	for j, e := range c.Elements {
+		if totalcosting.IsNormalLoss(e.Type) {
+			if loss >= 0 {
+				return false
+			}
+			loss = j
+		}
+	}
+	if loss < 0 || last < 0 || c.Elements[last].NDBurden > 0 || c.Elements[last].Unit == 0 {
+		return false
+	}
+
+	lossCost := c.Elements[loss].Cost()
+
+	// 正しい配分を取り消す
+	total := c.GetTotalNDBurden()
+	for k := range c.Elements {
+		e := &c.Elements[k]
+		if e.NDBurden > 0 && e.Unit > 0 && total > 0 {
+			e.AddCost(-lossCost * float64(e.NDBurden) / float64(total))
+		}
+	}
+
+	e := &c.Elements[last]
+	e.NDBurden = e.Unit
+	if c.DMethod == totalcosting.NonNeglecting {
+		e.NDBurden = box.Master[last].Unit
+	}
+
+	total = c.GetTotalNDBurden()
+	for k := range c.Elements {
+		e := &c.Elements[k]
+		if e.NDBurden > 0 && e.Unit > 0 {
+			e.AddCost(lossCost * float64(e.NDBurden) / float64(total))
+		}
+	}
+
+	box.EOTMTotalCost = box.CalculationEOFMCost()
+	box.ProductTotalCost = box.CalculationProductCost()
+	box.ProductAvgCost = box.CalculationProductAvgCost()
+
+	return true
+}
+
+// truncate is 数量×加工進捗度の端数を切り捨てた完成品換算量になるように加工進捗度を変えたBoxを返す
+// 四捨五入と結果が変わる要素がなければfalseを返す
+func truncate(box totalcosting.Box) (totalcosting.Box, bool) {
+	v := box.Clone()
+	changed := false
+
+	for i, e := range v.Master {
+		if e.Type == totalcosting.Input || e.Type == totalcosting.Output || e.Unit == 0 {
+			continue
+		}
+
+		units := float64(e.Unit) * e.Progress
+		if math.Floor(units) == math.Round(units) {
+			continue
+		}
+
+		// 四捨五入すると切り捨てた値になる加工進捗度にする
+		// 正常仕損を負担するかや直接材料を投入済みかが変わるなら切り捨てない
+		progress := (math.Floor(units) + 0.25) / float64(e.Unit)
+		if crossesLoss(box, e.Progress, progress) || crossesInput(box, e.Progress, progress) {
+			continue
+		}
+
+		v.Master[i].Progress = progress
+		changed = true
+	}
+
+	return v, changed
+}
+
+// sameResult is 2つのRun済みのBoxの結果が同じか判定
+// 浮動小数点の誤差は無視する
+func sameResult(a totalcosting.Box, b totalcosting.Box) bool {
+	const epsilon = 1e-6
+
+	return math.Abs(a.ProductTotalCost-b.ProductTotalCost) < epsilon &&
+		math.Abs(a.EOTMTotalCost-b.EOTMTotalCost) < epsilon &&
+		reflect.DeepEqual(units(a), units(b))
+}
+
+// units is 原価要素ごとの完成品換算量を返す
+func units(box totalcosting.Box) [][]int {
+	result := make([][]int, len(box.Costs))
+
+	for i, c := range box.Costs {
+		for _, e := range c.Elements {
+			result[i] = append(result[i], e.Unit)
+		}
+	}
+
+	return result
+}
+
+// crossesLoss is 加工進捗度をfromからtoに変えると正常仕損の発生点をまたぐか判定
+func crossesLoss(box totalcosting.Box, from float64, to float64) bool {
+	for _, e := range box.Master {
+		if totalcosting.IsNormalLoss(e.Type) && to < e.Progress && e.Progress <= from {
+			return true
+		}
+	}
+
+	return false
+}
+
+// crossesInput is 加工進捗度をfromからtoに変えると定点投入の投入点をまたぐか判定
+func crossesInput(box totalcosting.Box, from float64, to float64) bool {
+	for _, c := range box.Costs {
+		if !c.InputOnAvg && to < c.InputTiming && c.InputTiming <= from {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasNormalLoss is 正常仕損か正常減損があるか判定
+func hasNormalLoss(box totalcosting.Box) bool {
+	for _, e := range box.Master {
+		if totalcosting.IsNormalLoss(e.Type) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,171 @@
+package diagnosis
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/answer"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFIFOBox is 先入先出法, 材料は始点投入の問題
+func newFIFOBox() totalcosting.Box {
+	return totalcostingtest.Box(totalcosting.FIFO, totalcosting.Neglecting)
+}
+
+// newAVGBox is 平均法, 材料は始点投入の問題
+func newAVGBox() totalcosting.Box {
+	return totalcostingtest.Box(totalcosting.AVG, totalcosting.Neglecting)
+}
+
+// newLossBox is 終点で正常仕損が発生し, 月末仕掛品は負担しない問題
+func newLossBox() totalcosting.Box {
+	return totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.Input, Unit: 2000},
+			{Type: totalcosting.Output, Unit: 1700},
+			{Type: totalcosting.NormalDefect, Unit: 100, Progress: 1},
+			{Type: totalcosting.Last, Unit: 201, Progress: 0.5},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "加工費", InputOnAvg: true, CMethod: totalcosting.AVG, DMethod: totalcosting.Neglecting, InputCost: 1900000},
+		},
+	}
+}
+
+// newBearingLossBox is 月末仕掛品も正常仕損費を負担する問題
+func newBearingLossBox() totalcosting.Box {
+	box := newLossBox()
+	box.Master[2].Progress = 0.4
+
+	return box
+}
+
+// answerOf is Run済みのBoxの結果をそのまま解答にする
+func answerOf(box totalcosting.Box) answer.Answer {
+	return answer.Answer{
+		ProductTotalCost: &box.ProductTotalCost,
+		EOTMTotalCost:    &box.EOTMTotalCost,
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	testCases := []struct {
+		Box     func() totalcosting.Box
+		Mistake func(b *totalcosting.Box) // 学習者の誤り
+		Result  string
+		Cost    int
+	}{
+		{newFIFOBox, func(b *totalcosting.Box) { b.Costs[1].CMethod = totalcosting.AVG; b.Run() }, AVGInsteadOfFIFO, 1},
+		{newFIFOBox, func(b *totalcosting.Box) {
+			b.Costs[0].CMethod = totalcosting.AVG
+			b.Costs[1].CMethod = totalcosting.AVG
+			b.Run()
+		}, AVGInsteadOfFIFO, -1},
+		{newFIFOBox, func(b *totalcosting.Box) { b.Costs[0].InputOnAvg = true; b.Run() }, UniformInsteadOfPointInput, 0},
+		{newBearingLossBox, func(b *totalcosting.Box) { b.Costs[0].DMethod = totalcosting.NonNeglecting; b.Run() }, NonInsteadOfNeglecting, 0},
+		{newAVGBox, func(b *totalcosting.Box) { b.Costs[0].CMethod = totalcosting.FIFO; b.Run() }, FIFOInsteadOfAVG, 0},
+		{newLossBox, func(b *totalcosting.Box) { b.Run(); bearLast(b, 0) }, WIPBearsBeforeInspection, 0},
+		{newLossBox, func(b *totalcosting.Box) { b.Master[3].Progress = 0.4975; b.Run() }, TruncatedUnits, -1},
+	}
+
+	for _, testCase := range testCases {
+		learner := testCase.Box()
+		testCase.Mistake(&learner)
+
+		d, err := Diagnose(testCase.Box(), answerOf(learner), answer.DefaultTolerance)
+		if err != nil || d.Result.Correct == d.Result.Total || len(d.Matches) == 0 {
+			t.Errorf("Invalid result. mistake:%s, actual:%#v %v", testCase.Result, d, err)
+			continue
+		}
+
+		m := d.Matches[0]
+		if m.Mistake != testCase.Result || m.Cost != testCase.Cost || !m.Complete {
+			t.Errorf("Invalid result. mistake:%s, actual:%#v", testCase.Result, d.Matches)
+		}
+	}
+}
+
+func TestDiagnoseCorrect(t *testing.T) {
+	box := newFIFOBox()
+	box.Run()
+
+	d, err := Diagnose(newFIFOBox(), answerOf(box), answer.DefaultTolerance)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, d.Result.Correct)
+	assert.Empty(t, d.Matches)
+
+	// どの誤りでも説明できない解答
+	wrong := 1.0
+	d, err = Diagnose(newFIFOBox(), answer.Answer{ProductTotalCost: &wrong}, answer.DefaultTolerance)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, d.Result.Correct)
+	assert.Empty(t, d.Matches)
+
+	_, err = Diagnose(newFIFOBox(), answer.Answer{}, -1)
+	assert.Error(t, err)
+}
+
+func TestVariants(t *testing.T) {
+	box := newLossBox()
+	variants := Variants(box)
+
+	mistakes := make([]string, len(variants))
+	for i, v := range variants {
+		mistakes[i] = v.Mistake
+	}
+	// 月初仕掛品がないので平均法と先入先出法は同じ, 完成品だけが負担するので度外視法と非度外視法も同じ
+	assert.Equal(t, []string{WIPBearsBeforeInspection, TruncatedUnits}, mistakes)
+	assert.Equal(t, "加工費の正常仕損費を発生点に達していない月末仕掛品にも負担させた", variants[0].Description)
+
+	// 元の問題は変わらない
+	assert.Equal(t, newLossBox(), box)
+
+	// 切り捨てた換算量
+	last := variants[1].Box.Costs[0].Elements[3]
+	assert.Equal(t, 100, last.Unit)
+}
+
+func TestTruncate(t *testing.T) {
+	testCases := []struct {
+		Unit     int
+		Progress float64
+		Changed  bool
+	}{
+		{201, 0.5, true},
+		{200, 0.5, false},
+		{201, 0.3, false},
+		{1, 0.4, false},
+	}
+
+	for _, testCase := range testCases {
+		box := newLossBox()
+		box.Master[3].Unit = testCase.Unit
+		box.Master[3].Progress = testCase.Progress
+
+		_, changed := truncate(box)
+		if changed != testCase.Changed {
+			t.Errorf("Invalid result. testCase:%#v, actual:%v", testCase, changed)
+		}
+	}
+
+	// 正常仕損の発生点をまたぐなら切り捨てない
+	box := newLossBox()
+	box.Master[2].Progress = 0.5
+	_, changed := truncate(box)
+	assert.False(t, changed)
+
+	// 定点投入の投入点をまたぐなら切り捨てない
+	// 245 * 0.5 = 122.5 を切り捨てると加工進捗度は 0.49898 で投入点 0.5 より前になる
+	box = newLossBox()
+	box.Master[3].Unit = 245
+	box.Costs = append(box.Costs, totalcosting.Cost{Name: "直接材料費", InputTiming: 0.5, CMethod: totalcosting.AVG, DMethod: totalcosting.Neglecting, InputCost: 490000})
+	_, changed = truncate(box)
+	assert.False(t, changed)
+
+	// 始点投入なら切り捨てる
+	box.Costs[1].InputTiming = 0
+	_, changed = truncate(box)
+	assert.True(t, changed)
+}
@@ -0,0 +1,143 @@
+package expensecosting
+
+import (
+	"fmt"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/materialcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// ExpenseType は経費の種別を表す
+type ExpenseType int
+
+// 経費の種別(支払経費, 月割経費, 測定経費, 発生経費)
+const (
+	Payment ExpenseType = iota
+	Monthly
+	Measured
+	Occurred
+)
+
+// Expense is 経費の費目1つ
+// 種別ごとに使うフィールドが異なる
+type Expense struct {
+	Name   string
+	Type   ExpenseType
+	Direct bool // 直接経費か
+
+	// 支払経費
+	Paid        float64 // 当月支払額
+	PrevPrepaid float64 // 前月前払
+	PrevAccrued float64 // 前月未払
+	CurrPrepaid float64 // 当月前払
+	CurrAccrued float64 // 当月未払
+
+	// 月割経費, 発生経費
+	Amount float64 // 月割経費は期間の総額, 発生経費は当月発生額
+	Months int     // 月割経費の月数
+
+	// 測定経費
+	BasicCharge float64 // 基本料金
+	UnitPrice   float64 // 従量単価
+	PrevReading float64 // 前月検針量
+	CurrReading float64 // 当月検針量
+}
+
+// Cost is 当月の経費消費額を返す
+func (e Expense) Cost() (float64, error) {
+	switch e.Type {
+	case Payment:
+		return e.Paid + e.PrevPrepaid - e.PrevAccrued - e.CurrPrepaid + e.CurrAccrued, nil
+	case Monthly:
+		if e.Months <= 0 {
+			return 0, fmt.Errorf("expensecosting: %s: months must be positive", e.Name)
+		}
+		return e.Amount / float64(e.Months), nil
+	case Measured:
+		if e.CurrReading < e.PrevReading {
+			return 0, fmt.Errorf("expensecosting: %s: reading decreased", e.Name)
+		}
+		return e.BasicCharge + e.UnitPrice*(e.CurrReading-e.PrevReading), nil
+	case Occurred:
+		return e.Amount, nil
+	}
+
+	return 0, fmt.Errorf("expensecosting: %s: unknown expense type %d", e.Name, e.Type)
+}
+
+// ShrinkageExpense is 材料元帳の棚卸減耗費を発生経費として返す
+func ShrinkageExpense(l materialcosting.Ledger) Expense {
+	return Expense{
+		Name:   "棚卸減耗費",
+		Type:   Occurred,
+		Amount: l.ShrinkageCost,
+	}
+}
+
+// Calculation is 経費の計算
+type Calculation struct {
+	Expenses     []Expense
+	Costs        []float64 // Expensesごとの消費額
+	DirectCost   float64   // 直接経費
+	IndirectCost float64   // 間接経費
+}
+
+// Run is 経費を集計する
+func (c *Calculation) Run() error {
+	c.Costs = make([]float64, len(c.Expenses))
+	c.DirectCost = 0
+	c.IndirectCost = 0
+
+	for i, e := range c.Expenses {
+		cost, err := e.Cost()
+		if err != nil {
+			return err
+		}
+
+		c.Costs[i] = cost
+
+		if e.Direct {
+			c.DirectCost += cost
+		} else {
+			c.IndirectCost += cost
+		}
+	}
+
+	return nil
+}
+
+// GetTotalByType is 種別ごとの消費額合計を返す
+func (c Calculation) GetTotalByType(t ExpenseType) float64 {
+	total := 0.0
+
+	for i, e := range c.Expenses {
+		if e.Type == t && i < len(c.Costs) {
+			total += c.Costs[i]
+		}
+	}
+
+	return total
+}
+
+// AddTo is 直接経費をCostの当月投入原価に加算する
+func (c Calculation) AddTo(cost *totalcosting.Cost) {
+	cost.InputCost += c.DirectCost
+}
+
+// Overhead is 製造間接費
+type Overhead struct {
+	IndirectMaterial float64 // 間接材料費
+	IndirectLabor    float64 // 間接労務費
+	IndirectExpense  float64 // 間接経費
+}
+
+// Total is 製造間接費の合計を返す
+func (o Overhead) Total() float64 {
+	return o.IndirectMaterial + o.IndirectLabor + o.IndirectExpense
+}
+
+// AddTo is 製造間接費のうちratioの割合をCostの当月投入原価に配賦する
+// 単一工程ならratioは1.0
+func (o Overhead) AddTo(cost *totalcosting.Cost, ratio float64) {
+	cost.InputCost += o.Total() * ratio
+}
@@ -0,0 +1,76 @@
+package expensecosting
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/materialcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCost(t *testing.T) {
+	testCases := []struct {
+		E      Expense
+		Result float64
+	}{
+		{Expense{Type: Payment, Paid: 50000.0, PrevAccrued: 8000.0, CurrAccrued: 6000.0}, 48000.0},
+		{Expense{Type: Payment, Paid: 30000.0, PrevPrepaid: 2000.0, CurrPrepaid: 5000.0}, 27000.0},
+		{Expense{Type: Monthly, Amount: 1200000.0, Months: 12}, 100000.0},
+		{Expense{Type: Measured, BasicCharge: 10000.0, UnitPrice: 25.0, PrevReading: 1200, CurrReading: 3200}, 60000.0},
+		{Expense{Type: Occurred, Amount: 5500.0}, 5500.0},
+	}
+
+	for _, testCase := range testCases {
+		result, err := testCase.E.Cost()
+		if err != nil || result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%f, err:%v", testCase, result, err)
+		}
+	}
+}
+
+func TestCostError(t *testing.T) {
+	testCases := []Expense{
+		{Type: Monthly, Amount: 1200000.0},
+		{Type: Measured, PrevReading: 3200, CurrReading: 1200},
+		{Type: ExpenseType(99)},
+	}
+
+	for _, testCase := range testCases {
+		_, err := testCase.Cost()
+		assert.Error(t, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ledger := materialcosting.Ledger{Stocktaking: true, FirstUnit: 10, FirstPrice: 550.0}
+	assert.NoError(t, ledger.Run())
+
+	calc := Calculation{
+		Expenses: []Expense{
+			{Name: "外注加工賃", Type: Payment, Direct: true, Paid: 80000.0},
+			{Name: "減価償却費", Type: Monthly, Amount: 1200000.0, Months: 12},
+			{Name: "電力料", Type: Measured, BasicCharge: 10000.0, UnitPrice: 25.0, CurrReading: 2000},
+			ShrinkageExpense(ledger),
+		},
+	}
+
+	err := calc.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 80000.0, calc.DirectCost)
+	assert.Equal(t, 165500.0, calc.IndirectCost)
+	assert.Equal(t, 100000.0, calc.GetTotalByType(Monthly))
+	assert.Equal(t, 5500.0, calc.GetTotalByType(Occurred))
+}
+
+func TestOverheadAddTo(t *testing.T) {
+	overhead := Overhead{
+		IndirectMaterial: 43000.0,
+		IndirectLabor:    220000.0,
+		IndirectExpense:  165500.0,
+	}
+	assert.Equal(t, 428500.0, overhead.Total())
+
+	processing := totalcosting.Cost{InputCost: 440000.0}
+	overhead.AddTo(&processing, 0.4)
+	assert.Equal(t, 611400.0, processing.InputCost)
+}
@@ -0,0 +1,392 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Material is 直接材料の投入方法
+type Material string
+
+// 直接材料の投入方法
+const (
+	MaterialStart   Material = "start"   // 始点投入
+	MaterialPoint   Material = "point"   // 工程の途中で投入
+	MaterialUniform Material = "uniform" // 平均的投入
+)
+
+// Defect is 正常仕損の有無と, 発生点と月末仕掛品の加工進捗度の関係
+type Defect string
+
+// 正常仕損の有無と発生点
+const (
+	DefectNone   Defect = "none"   // 仕損なし
+	DefectBoth   Defect = "both"   // 月末仕掛品が発生点を通過している(両者負担)
+	DefectOutput Defect = "output" // 月末仕掛品が発生点に達していない(完成品のみ負担)
+)
+
+// Difficulty is 問題の難易度
+type Difficulty string
+
+// 問題の難易度
+const (
+	Easy   Difficulty = "easy"   // 数量は100個, 加工進捗度は1/4刻み
+	Normal Difficulty = "normal" // 数量は50個, 加工進捗度は1/5刻み
+	Hard   Difficulty = "hard"   // 数量は50個, 加工進捗度は1/10刻みで10個刻みの異常仕損もある
+)
+
+// maxAttempts is 条件を満たす問題を探す回数の上限
+const maxAttempts = 20000
+
+// Options is 生成する問題の論点
+// Seedが同じなら同じ問題を生成する
+type Options struct {
+	Seed       int64                               `json:"seed"`
+	CMethod    totalcosting.CalculationMethod      `json:"calculation_method"`
+	DMethod    totalcosting.DefectiveProductMethod `json:"defective_product_method"`
+	Material   Material                            `json:"material"`
+	Defect     Defect                              `json:"defect"`
+	Difficulty Difficulty                          `json:"difficulty"`
+}
+
+// DefaultOptions is 平均法, 度外視法, 材料は始点投入, 仕損なし, 普通の難易度の論点を返す
+func DefaultOptions(seed int64) Options {
+	return Options{
+		Seed:       seed,
+		CMethod:    totalcosting.AVG,
+		DMethod:    totalcosting.Neglecting,
+		Material:   MaterialStart,
+		Defect:     DefectNone,
+		Difficulty: Normal,
+	}
+}
+
+// level is 難易度ごとの数値の細かさ
+type level struct {
+	step        int  // 数量の刻み
+	denominator int  // 加工進捗度の分母
+	priceStep   int  // 単価の刻み
+	abnormal    bool // 異常仕損を含める
+}
+
+// levels is 難易度ごとの数値の細かさ
+var levels = map[Difficulty]level{
+	Easy:   {step: 100, denominator: 4, priceStep: 10},
+	Normal: {step: 50, denominator: 5, priceStep: 5},
+	Hard:   {step: 50, denominator: 10, priceStep: 1, abnormal: true},
+}
+
+// priceRange is 原価要素ごとの当月投入単価の範囲
+var priceRange = []struct {
+	Name string
+	Min  int
+	Max  int
+}{
+	{"直接材料費", 100, 800},
+	{"加工費", 200, 1500},
+}
+
+// Validate is 論点が生成できる組み合わせか確認する
+func (o Options) Validate() error {
+	if o.CMethod != totalcosting.FIFO && o.CMethod != totalcosting.AVG {
+		return fmt.Errorf("generator: unknown calculation method %d", int(o.CMethod))
+	}
+	if o.DMethod != totalcosting.Neglecting && o.DMethod != totalcosting.NonNeglecting {
+		return fmt.Errorf("generator: unknown defective product method %d", int(o.DMethod))
+	}
+
+	switch o.Material {
+	case MaterialStart, MaterialPoint, MaterialUniform:
+	default:
+		return fmt.Errorf("generator: unknown material %q", o.Material)
+	}
+
+	switch o.Defect {
+	case DefectNone, DefectBoth, DefectOutput:
+	default:
+		return fmt.Errorf("generator: unknown defect %q", o.Defect)
+	}
+
+	if _, ok := levels[o.Difficulty]; !ok {
+		return fmt.Errorf("generator: unknown difficulty %q", o.Difficulty)
+	}
+
+	return nil
+}
+
+// Generate is 論点に合うRun前の問題を生成する
+// 単価, 配分額, 完成品原価, 完成品単位原価, 月末仕掛品原価がすべて円未満の端数なく割り切れる
+func Generate(o Options) (totalcosting.Box, error) {
+	if err := o.Validate(); err != nil {
+		return totalcosting.Box{}, err
+	}
+
+	g := generator{rand: rand.New(rand.NewSource(o.Seed)), options: o, level: levels[o.Difficulty]}
+
+	for i := 0; i < maxAttempts; i++ {
+		master, timing, ok := g.master()
+		if !ok {
+			continue
+		}
+
+		if box, ok := g.box(master, timing); ok {
+			return box, nil
+		}
+	}
+
+	return totalcosting.Box{}, fmt.Errorf("generator: no problem with whole yen answers found for seed %d", o.Seed)
+}
+
+// generator is 問題を1つ生成する間の状態
+type generator struct {
+	rand    *rand.Rand
+	options Options
+	level   level
+}
+
+// units is min以上max以下でstepの倍数の数量を返す
+func (g generator) units(min int, max int, step int) int {
+	if min < step {
+		min = step
+	}
+
+	return step * (min/step + g.rand.Intn(max/step-min/step+1))
+}
+
+// progress is 分子がmin以上max以下の加工進捗度を返す
+// 範囲が空ならfalseを返す
+func (g generator) progress(min int, max int) (float64, bool) {
+	if min > max {
+		return 0, false
+	}
+
+	return float64(min+g.rand.Intn(max-min+1)) / float64(g.level.denominator), true
+}
+
+// master is 物量の要素と材料の投入点を決める
+// 論点に合う加工進捗度が選べなければfalseを返す
+func (g generator) master() ([]totalcosting.Element, float64, bool) {
+	d := g.level.denominator
+
+	output := 100 * (10 + g.rand.Intn(21))
+	first := totalcosting.Element{Type: totalcosting.First, Unit: g.units(200, 800, g.level.step)}
+	last := totalcosting.Element{Type: totalcosting.Last, Unit: g.units(200, 800, g.level.step)}
+	var losses []totalcosting.Element
+
+	// 分子で選んでから加工進捗度にする
+	var lossPoint, lastPoint, firstPoint int
+	switch g.options.Defect {
+	case DefectNone:
+		lastPoint = 1 + g.rand.Intn(d-1)
+		firstPoint = 1 + g.rand.Intn(d-1)
+	case DefectBoth:
+		lossPoint = 1 + g.rand.Intn(d-2)
+		lastPoint = lossPoint + 1 + g.rand.Intn(d-1-lossPoint)
+	case DefectOutput:
+		lossPoint = 2 + g.rand.Intn(d-1)
+		lastPoint = 1 + g.rand.Intn(lossPoint-1)
+	}
+
+	if g.options.Defect != DefectNone {
+		// 先入先出法では月初仕掛品は前月に発生点を通過しているものとする
+		min := 1
+		if g.options.CMethod == totalcosting.FIFO {
+			min = lossPoint
+		}
+		if min > d-1 {
+			return nil, 0, false
+		}
+		firstPoint = min + g.rand.Intn(d-min)
+
+		losses = append(losses, totalcosting.Element{
+			Type:     totalcosting.NormalDefect,
+			Unit:     g.units(100, 200, g.level.step),
+			Progress: float64(lossPoint) / float64(d),
+		})
+
+		// 異常仕損は正常仕損の発生点より後で発生し, 正常仕損費を負担する
+		if g.level.abnormal {
+			p, ok := g.progress(lossPoint+1, d)
+			if !ok || p == float64(lastPoint)/float64(d) {
+				return nil, 0, false
+			}
+			losses = append(losses, totalcosting.Element{Type: totalcosting.AbnormalDefect, Unit: g.units(10, 50, 10), Progress: p})
+		}
+	}
+
+	first.Progress = float64(firstPoint) / float64(d)
+	last.Progress = float64(lastPoint) / float64(d)
+
+	input := output + last.Unit - first.Unit
+	for _, e := range losses {
+		input += e.Unit
+	}
+
+	master := []totalcosting.Element{
+		first,
+		{Type: totalcosting.Input, Unit: input},
+		{Type: totalcosting.Output, Unit: output},
+		last,
+	}
+	master = append(master, losses...)
+
+	// 途中点投入の投入点はどの要素の加工進捗度とも重ならないようにする
+	timing := 0.0
+	if g.options.Material == MaterialPoint {
+		var ok bool
+		if timing, ok = g.progress(1, d-1); !ok {
+			return nil, 0, false
+		}
+		for _, e := range master {
+			if e.Progress == timing {
+				return nil, 0, false
+			}
+		}
+	}
+
+	return master, timing, true
+}
+
+// box is 物量に原価を加えた問題を作る
+// 割り切れる原価が選べなければfalseを返す
+func (g generator) box(master []totalcosting.Element, timing float64) (totalcosting.Box, bool) {
+	box := totalcosting.Box{Master: master}
+
+	for i, r := range priceRange {
+		c := totalcosting.Cost{
+			Name:        r.Name,
+			InputOnAvg:  true,
+			InputTiming: timing,
+			CMethod:     g.options.CMethod,
+			DMethod:     g.options.DMethod,
+		}
+		if i == 0 && g.options.Material != MaterialUniform {
+			c.InputOnAvg = false
+		}
+
+		c, ok := g.cost(master, c, r.Min, r.Max)
+		if !ok {
+			return totalcosting.Box{}, false
+		}
+		box.Costs = append(box.Costs, c)
+	}
+
+	if box.Validate() != nil {
+		return totalcosting.Box{}, false
+	}
+
+	// 念のため解いて確かめる
+	solved := totalcosting.Box{Master: box.Master, Costs: append([]totalcosting.Cost{}, box.Costs...)}
+	solved.Run()
+	if !isClean(solved) {
+		return totalcosting.Box{}, false
+	}
+
+	return box, true
+}
+
+// cost is 単価が1円になる原価で解いてから, すべての金額が割り切れる単価を選ぶ
+// 完成品単位原価も割り切れるように, 先入先出法では月初仕掛品原価を, 平均法では単価を選ぶ
+func (g generator) cost(master []totalcosting.Element, c totalcosting.Cost, min int, max int) (totalcosting.Cost, bool) {
+	probe := totalcosting.Box{Master: master, Costs: []totalcosting.Cost{c}}
+	probe.Run()
+
+	e := probe.Costs[0].Elements
+	firstUnit, inputUnit := 0, 0
+	if k := totalcosting.Index(totalcosting.First, e); k >= 0 {
+		firstUnit = e[k].Unit
+	}
+	if k := totalcosting.Index(totalcosting.Input, e); k >= 0 {
+		inputUnit = e[k].Unit
+	}
+
+	basis := inputUnit
+	if c.CMethod == totalcosting.AVG {
+		basis += firstUnit
+	}
+	if basis <= 0 {
+		return c, false
+	}
+
+	probe.Costs[0].InputCost = float64(basis)
+	probe.Run()
+
+	// 先入先出法で月初仕掛品原価を調整できるなら完成品単位原価は後で割り切れるようにする
+	adjustFirst := c.CMethod == totalcosting.FIFO && firstUnit > 0
+	var values []float64
+	for _, step := range probe.Trace {
+		if adjustFirst && step.Key == "product_avg_cost" {
+			continue
+		}
+		values = append(values, step.Value)
+	}
+
+	// 単価はmin以上max以下のmの倍数
+	m := multiplier(values, g.level.priceStep, max)
+	if m == 0 || (min+m-1)/m > max/m {
+		return c, false
+	}
+	lo, hi := (min+m-1)/m, max/m
+	price := m * (lo + g.rand.Intn(hi-lo+1))
+
+	// 月初仕掛品の単価は当月投入の単価の8割から12割
+	step := g.level.priceStep
+	firstPrice := price * (80 + g.rand.Intn(41)) / 100 / step * step
+	c.FirstCost = float64(firstPrice * firstUnit)
+
+	if c.CMethod == totalcosting.AVG {
+		c.InputCost = float64(price*basis) - c.FirstCost
+		if c.InputCost < 0 {
+			return c, false
+		}
+		return c, true
+	}
+
+	c.InputCost = float64(price * basis)
+	if adjustFirst {
+		// 月初仕掛品原価を完成品原価が完成品数量で割り切れるまで増やす
+		output := master[totalcosting.Index(totalcosting.Output, master)].Unit
+		outputCost := int(math.Round(probe.ProductTotalCost)) * price
+		c.FirstCost += float64(((-(int(c.FirstCost) + outputCost))%output + output) % output)
+	}
+
+	return c, true
+}
+
+// multiplier is valuesをすべて整数にするstepの倍数のうち最小のものを返す
+// maxまでに見つからなければ0を返す
+func multiplier(values []float64, step int, max int) int {
+	for m := step; m <= max; m += step {
+		clean := true
+		for _, v := range values {
+			if !isWhole(v * float64(m)) {
+				clean = false
+				break
+			}
+		}
+		if clean {
+			return m
+		}
+	}
+
+	return 0
+}
+
+// isClean is Run済みのBoxの計算過程と結果がすべて円未満の端数なく割り切れているか判定
+func isClean(box totalcosting.Box) bool {
+	for _, step := range box.Trace {
+		if !isWhole(step.Value) {
+			return false
+		}
+	}
+
+	return isWhole(box.ProductTotalCost) && isWhole(box.ProductAvgCost) && isWhole(box.EOTMTotalCost)
+}
+
+// isWhole is 浮動小数点の誤差を除いて整数か判定
+func isWhole(v float64) bool {
+	return math.Abs(v-math.Round(v)) < 1e-6
+}
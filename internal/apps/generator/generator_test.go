@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, cMethod := range []totalcosting.CalculationMethod{totalcosting.FIFO, totalcosting.AVG} {
+		for _, dMethod := range []totalcosting.DefectiveProductMethod{totalcosting.Neglecting, totalcosting.NonNeglecting} {
+			for _, material := range []Material{MaterialStart, MaterialPoint, MaterialUniform} {
+				for _, defect := range []Defect{DefectNone, DefectBoth, DefectOutput} {
+					for _, difficulty := range []Difficulty{Easy, Normal, Hard} {
+						for seed := int64(1); seed <= 2; seed++ {
+							o := Options{seed, cMethod, dMethod, material, defect, difficulty}
+							box, err := Generate(o)
+							if err != nil {
+								t.Errorf("Invalid result. options:%#v, actual:%v", o, err)
+								continue
+							}
+
+							if err := box.Validate(); err != nil {
+								t.Errorf("Invalid result. options:%#v, actual:%v", o, err)
+							}
+							assertFeatures(t, o, box)
+
+							box.Run()
+							if !isClean(box) {
+								t.Errorf("Invalid result. options:%#v, actual:%#v", o, box.Trace)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// assertFeatures is 生成した問題が論点どおりか確認する
+func assertFeatures(t *testing.T, o Options, box totalcosting.Box) {
+	t.Helper()
+
+	for _, c := range box.Costs {
+		assert.Equal(t, o.CMethod, c.CMethod)
+		assert.Equal(t, o.DMethod, c.DMethod)
+		assert.Empty(t, c.Elements)
+	}
+
+	material := box.Costs[0]
+	switch o.Material {
+	case MaterialStart:
+		assert.False(t, material.InputOnAvg)
+		assert.Equal(t, 0.0, material.InputTiming)
+	case MaterialPoint:
+		assert.False(t, material.InputOnAvg)
+		assert.True(t, material.InputTiming > 0 && material.InputTiming < 1)
+	case MaterialUniform:
+		assert.True(t, material.InputOnAvg)
+	}
+	assert.True(t, box.Costs[1].InputOnAvg)
+
+	last := box.Master[totalcosting.Index(totalcosting.Last, box.Master)]
+	loss := totalcosting.GetNormalDefectProgress(box.Master)
+	switch o.Defect {
+	case DefectNone:
+		assert.Equal(t, -1.0, loss)
+	case DefectBoth:
+		assert.True(t, last.IsBear(loss), "last %v, loss %v", last.Progress, loss)
+	case DefectOutput:
+		assert.False(t, last.IsBear(loss), "last %v, loss %v", last.Progress, loss)
+	}
+
+	abnormal := totalcosting.Index(totalcosting.AbnormalDefect, box.Master) >= 0
+	assert.Equal(t, o.Difficulty == Hard && o.Defect != DefectNone, abnormal)
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	o := Options{42, totalcosting.FIFO, totalcosting.NonNeglecting, MaterialPoint, DefectBoth, Normal}
+
+	a, err := Generate(o)
+	assert.NoError(t, err)
+	b, err := Generate(o)
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	o.Seed++
+	c, err := Generate(o)
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, c)
+}
+
+func TestGenerateError(t *testing.T) {
+	testCases := []struct {
+		Options Options
+	}{
+		{Options{CMethod: 2, Material: MaterialStart, Defect: DefectNone, Difficulty: Easy}},
+		{Options{DMethod: 2, Material: MaterialStart, Defect: DefectNone, Difficulty: Easy}},
+		{Options{Material: "end", Defect: DefectNone, Difficulty: Easy}},
+		{Options{Material: MaterialStart, Defect: "abnormal", Difficulty: Easy}},
+		{Options{Material: MaterialStart, Defect: DefectNone}},
+	}
+
+	for _, testCase := range testCases {
+		if _, err := Generate(testCase.Options); err == nil {
+			t.Errorf("Invalid result. testCase:%#v, actual:nil", testCase)
+		}
+	}
+
+	assert.NoError(t, DefaultOptions(0).Validate())
+}
+
+func TestMultiplier(t *testing.T) {
+	testCases := []struct {
+		Values []float64
+		Step   int
+		Max    int
+		Result int
+	}{
+		{[]float64{1, 2}, 1, 10, 1},
+		{[]float64{0.5, 1.0 / 3}, 1, 10, 6},
+		{[]float64{0.5, 1.0 / 3}, 5, 100, 30},
+		{[]float64{1.0 / 7}, 1, 6, 0},
+	}
+
+	for _, testCase := range testCases {
+		result := multiplier(testCase.Values, testCase.Step, testCase.Max)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d", testCase, result)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package journal
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/expensecosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/laborcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/materialcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// ErrScrapValue is 仕損品の評価額を異常仕損費から控除できない
+var ErrScrapValue = errors.New("journal: scrap value must be between 0 and the abnormal defect cost")
+
+// Book は仕訳を記帳する帳簿を表す
+type Book int
+
+// 帳簿の種別(本社工場を分けない帳簿, 本社, 工場)
+const (
+	Unified Book = iota
+	HeadOffice
+	Factory
+)
+
+// String is 帳簿の名前を返す
+func (b Book) String() string {
+	switch b {
+	case HeadOffice:
+		return "本社"
+	case Factory:
+		return "工場"
+	}
+
+	return "一般"
+}
+
+// Account is 勘定科目
+type Account struct {
+	Code    string
+	Name    string
+	Factory bool // 工場会計独立のとき工場の帳簿に置く勘定か
+}
+
+// Accounts is 仕訳で使う勘定科目の一覧
+type Accounts struct {
+	Material         Account // 材料
+	Wage             Account // 賃金
+	Expense          Account // 経費
+	Overhead         Account // 製造間接費
+	WIP              Account // 仕掛品
+	Product          Account // 製品
+	CostOfSales      Account // 売上原価
+	AbnormalDefect   Account // 異常仕損費
+	DefectiveGoods   Account // 仕損品
+	MaterialVariance Account // 材料消費価格差異
+	RateVariance     Account // 賃率差異
+	HeadOffice       Account // 本社(工場の帳簿に置く)
+	Factory          Account // 工場(本社の帳簿に置く)
+}
+
+// DefaultAccounts is 標準の勘定科目を返す
+func DefaultAccounts() Accounts {
+	return Accounts{
+		Material:         Account{"111", "材料", true},
+		Wage:             Account{"211", "賃金", true},
+		Expense:          Account{"212", "経費", false},
+		Overhead:         Account{"511", "製造間接費", true},
+		WIP:              Account{"112", "仕掛品", true},
+		Product:          Account{"113", "製品", true},
+		CostOfSales:      Account{"611", "売上原価", false},
+		AbnormalDefect:   Account{"711", "異常仕損費", false},
+		DefectiveGoods:   Account{"114", "仕損品", true},
+		MaterialVariance: Account{"521", "材料消費価格差異", false},
+		RateVariance:     Account{"522", "賃率差異", false},
+		HeadOffice:       Account{"311", "本社", true},
+		Factory:          Account{"191", "工場", false},
+	}
+}
+
+// Line is 借方または貸方の1行
+type Line struct {
+	Account Account
+	Amount  float64
+}
+
+// Entry is 仕訳1件
+type Entry struct {
+	Book        Book
+	Description string
+	Debits      []Line
+	Credits     []Line
+}
+
+// DebitTotal is 借方合計を返す
+func (e Entry) DebitTotal() float64 {
+	return total(e.Debits)
+}
+
+// CreditTotal is 貸方合計を返す
+func (e Entry) CreditTotal() float64 {
+	return total(e.Credits)
+}
+
+// IsBalanced is 貸借が一致しているか判定
+// 浮動小数点の誤差は許容する
+func (e Entry) IsBalanced() bool {
+	return math.Abs(e.DebitTotal()-e.CreditTotal()) < 1e-6
+}
+
+// Input is 仕訳の元になる計算結果
+// Box以外は任意でnilなら仕訳を作らない
+type Input struct {
+	Box        totalcosting.Box
+	Material   *materialcosting.Ledger
+	Labor      *laborcosting.Calculation
+	Expense    *expensecosting.Calculation
+	ScrapValue float64 // 異常仕損品の評価額
+	SoldCost   float64 // 販売した製品の原価
+}
+
+// Generator is 仕訳の生成
+type Generator struct {
+	Accounts    Accounts
+	Independent bool // 工場会計独立
+}
+
+// NewGenerator is 標準の勘定科目で本社工場を分けないGeneratorを返す
+func NewGenerator() Generator {
+	return Generator{Accounts: DefaultAccounts()}
+}
+
+// Generate is 計算結果から仕訳を作る
+// 金額が0の仕訳は作らない
+// 仕損品の評価額は異常仕損費から控除するので, 異常仕損費を超えるとエラー
+func (g Generator) Generate(in Input) ([]Entry, error) {
+	abnormal := GetAbnormalCost(in.Box)
+	if in.ScrapValue < 0 || in.ScrapValue > abnormal+1e-6 {
+		return nil, fmt.Errorf("%w: %g > %g", ErrScrapValue, in.ScrapValue, abnormal)
+	}
+
+	a := g.Accounts
+	var entries []Entry
+	overhead := 0.0
+
+	add := func(description string, debits []Line, credits []Line) {
+		debits = nonZero(debits)
+		credits = nonZero(credits)
+		if len(debits) == 0 && len(credits) == 0 {
+			return
+		}
+		entries = append(entries, g.split(Entry{
+			Description: description,
+			Debits:      debits,
+			Credits:     credits,
+		})...)
+	}
+
+	if m := in.Material; m != nil {
+		add("材料の消費",
+			[]Line{{a.WIP, m.DirectCost}, {a.Overhead, m.IndirectCost}},
+			[]Line{{a.Material, m.DirectCost + m.IndirectCost}})
+		add("棚卸減耗",
+			[]Line{{a.Overhead, m.ShrinkageCost}},
+			[]Line{{a.Material, m.ShrinkageCost}})
+		debits, credits := varianceLines(a.MaterialVariance, a.Material, m.PriceVariance)
+		add("材料消費価格差異", debits, credits)
+		overhead += m.IndirectCost + m.ShrinkageCost
+	}
+
+	if l := in.Labor; l != nil {
+		add("賃金の消費",
+			[]Line{{a.WIP, l.DirectCost}, {a.Overhead, l.IndirectCost}},
+			[]Line{{a.Wage, l.DirectCost + l.IndirectCost}})
+		debits, credits := varianceLines(a.RateVariance, a.Wage, l.RateVariance)
+		add("賃率差異", debits, credits)
+		overhead += l.IndirectCost
+	}
+
+	if e := in.Expense; e != nil {
+		add("経費の消費",
+			[]Line{{a.WIP, e.DirectCost}, {a.Overhead, e.IndirectCost}},
+			[]Line{{a.Expense, e.DirectCost + e.IndirectCost}})
+		overhead += e.IndirectCost
+	}
+
+	add("製造間接費の配賦",
+		[]Line{{a.WIP, overhead}},
+		[]Line{{a.Overhead, overhead}})
+
+	add("異常仕損費の計上",
+		[]Line{{a.AbnormalDefect, abnormal - in.ScrapValue}, {a.DefectiveGoods, in.ScrapValue}},
+		[]Line{{a.WIP, abnormal}})
+
+	add("完成品の振替",
+		[]Line{{a.Product, in.Box.ProductTotalCost}},
+		[]Line{{a.WIP, in.Box.ProductTotalCost}})
+
+	add("売上原価の計上",
+		[]Line{{a.CostOfSales, in.SoldCost}},
+		[]Line{{a.Product, in.SoldCost}})
+
+	return entries, nil
+}
+
+// GetAbnormalCost is 異常仕損と異常減損に配分された原価を返す
+// BoxはRun済みであること
+func GetAbnormalCost(b totalcosting.Box) float64 {
+	cost := 0.0
+
+	for _, c := range b.Costs {
+		for _, e := range c.Elements {
+			if e.Type == totalcosting.AbnormalDefect || e.Type == totalcosting.AbnormalImpairment {
+				cost += e.Cost()
+			}
+		}
+	}
+
+	return cost
+}
+
+// split is 工場会計独立の場合に仕訳を本社と工場の帳簿に分ける
+func (g Generator) split(e Entry) []Entry {
+	if !g.Independent {
+		e.Book = Unified
+		return []Entry{e}
+	}
+
+	factory := Entry{Book: Factory, Description: e.Description}
+	head := Entry{Book: HeadOffice, Description: e.Description}
+
+	for _, l := range e.Debits {
+		if l.Account.Factory {
+			factory.Debits = append(factory.Debits, l)
+		} else {
+			head.Debits = append(head.Debits, l)
+		}
+	}
+	for _, l := range e.Credits {
+		if l.Account.Factory {
+			factory.Credits = append(factory.Credits, l)
+		} else {
+			head.Credits = append(head.Credits, l)
+		}
+	}
+
+	if len(head.Debits) == 0 && len(head.Credits) == 0 {
+		return []Entry{factory}
+	}
+	if len(factory.Debits) == 0 && len(factory.Credits) == 0 {
+		return []Entry{head}
+	}
+
+	// 貸借の差額を本社勘定と工場勘定で埋める
+	balance(&factory, g.Accounts.HeadOffice)
+	balance(&head, g.Accounts.Factory)
+
+	return []Entry{head, factory}
+}
+
+// balance is 貸借差額をaccountで埋める
+func balance(e *Entry, account Account) {
+	diff := e.DebitTotal() - e.CreditTotal()
+
+	if diff > 0 {
+		e.Credits = append(e.Credits, Line{account, diff})
+	}
+	if diff < 0 {
+		e.Debits = append(e.Debits, Line{account, -diff})
+	}
+}
+
+// varianceLines is 差異の仕訳の借方と貸方を返す
+// variance = 予定消費額 - 実際消費額, 負なら借方差異
+func varianceLines(varianceAccount Account, account Account, variance float64) ([]Line, []Line) {
+	if variance < 0 {
+		return []Line{{varianceAccount, -variance}}, []Line{{account, -variance}}
+	}
+
+	return []Line{{account, variance}}, []Line{{varianceAccount, variance}}
+}
+
+// nonZero is 金額が0の行を除く
+func nonZero(lines []Line) []Line {
+	var result []Line
+
+	for _, l := range lines {
+		if l.Amount != 0 {
+			result = append(result, l)
+		}
+	}
+
+	return result
+}
+
+// total is 行の金額合計を返す
+func total(lines []Line) float64 {
+	sum := 0.0
+
+	for _, l := range lines {
+		sum += l.Amount
+	}
+
+	return sum
+}
+
+// CSVHeader is 仕訳CSVの見出し行
+var CSVHeader = []string{
+	"伝票番号", "日付", "帳簿",
+	"借方科目コード", "借方科目", "借方金額",
+	"貸方科目コード", "貸方科目", "貸方金額",
+	"摘要",
+}
+
+// WriteCSV is 仕訳を汎用の仕訳CSV形式で書き出す
+// 複合仕訳は同じ伝票番号の複数行になる
+func WriteCSV(w io.Writer, date string, entries []Entry) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		rows := len(e.Debits)
+		if len(e.Credits) > rows {
+			rows = len(e.Credits)
+		}
+
+		for j := 0; j < rows; j++ {
+			record := []string{strconv.Itoa(i + 1), date, e.Book.String()}
+			record = append(record, lineFields(e.Debits, j)...)
+			record = append(record, lineFields(e.Credits, j)...)
+			record = append(record, e.Description)
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// lineFields is j番目の行の科目コード, 科目, 金額を返す
+// 行がなければ空欄
+func lineFields(lines []Line, j int) []string {
+	if j >= len(lines) {
+		return []string{"", "", ""}
+	}
+
+	l := lines[j]
+
+	return []string{l.Account.Code, l.Account.Name, strconv.FormatFloat(l.Amount, 'f', -1, 64)}
+}
@@ -0,0 +1,160 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/laborcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/materialcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBox() totalcosting.Box {
+	return totalcosting.Box{
+		Costs: []totalcosting.Cost{
+			{
+				Elements: []totalcosting.Element{
+					{Type: totalcosting.Output, Price: 500.0, Unit: 100},
+					{Type: totalcosting.AbnormalDefect, Price: 500.0, Unit: 10},
+				},
+			},
+		},
+		ProductTotalCost: 50000.0,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	material := materialcosting.Ledger{
+		DirectCost:    30000.0,
+		IndirectCost:  2000.0,
+		ShrinkageCost: 500.0,
+		PriceVariance: -900.0,
+	}
+	labor := laborcosting.Calculation{
+		DirectCost:   20000.0,
+		IndirectCost: 3000.0,
+		RateVariance: 400.0,
+	}
+
+	entries, err := NewGenerator().Generate(Input{
+		Box:        newBox(),
+		Material:   &material,
+		Labor:      &labor,
+		ScrapValue: 1000.0,
+		SoldCost:   45000.0,
+	})
+	assert.NoError(t, err)
+
+	descriptions := []string{
+		"材料の消費",
+		"棚卸減耗",
+		"材料消費価格差異",
+		"賃金の消費",
+		"賃率差異",
+		"製造間接費の配賦",
+		"異常仕損費の計上",
+		"完成品の振替",
+		"売上原価の計上",
+	}
+	assert.Equal(t, len(descriptions), len(entries))
+
+	for i, e := range entries {
+		assert.Equal(t, descriptions[i], e.Description)
+		assert.Equal(t, Unified, e.Book)
+		assert.True(t, e.IsBalanced(), e.Description)
+	}
+
+	// 借方差異は差異勘定の借方
+	assert.Equal(t, "材料消費価格差異", entries[2].Debits[0].Account.Name)
+	// 貸方差異は差異勘定の貸方
+	assert.Equal(t, "賃率差異", entries[4].Credits[0].Account.Name)
+	// 製造間接費 = 間接材料費 + 棚卸減耗費 + 間接労務費
+	assert.Equal(t, 5500.0, entries[5].DebitTotal())
+	// 異常仕損費は評価額を控除
+	assert.Equal(t, Line{DefaultAccounts().AbnormalDefect, 4000.0}, entries[6].Debits[0])
+	// 販売した製品は製品勘定から売上原価勘定へ振り替える
+	assert.Equal(t, Line{DefaultAccounts().CostOfSales, 45000.0}, entries[8].Debits[0])
+	assert.Equal(t, Line{DefaultAccounts().Product, 45000.0}, entries[8].Credits[0])
+}
+
+func TestGenerateIndependent(t *testing.T) {
+	generator := NewGenerator()
+	generator.Independent = true
+
+	entries, err := generator.Generate(Input{Box: newBox()})
+	assert.NoError(t, err)
+
+	// 異常仕損費の計上は本社と工場に分かれる
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, HeadOffice, entries[0].Book)
+	assert.Equal(t, "工場", entries[0].Credits[0].Account.Name)
+	assert.Equal(t, Factory, entries[1].Book)
+	assert.Equal(t, "本社", entries[1].Debits[0].Account.Name)
+	assert.Equal(t, Factory, entries[2].Book)
+
+	for _, e := range entries {
+		assert.True(t, e.IsBalanced())
+	}
+}
+
+func TestGenerateScrapValue(t *testing.T) {
+	testCases := []struct {
+		Box        totalcosting.Box
+		ScrapValue float64
+		Result     bool
+	}{
+		// 異常仕損費5,000の全額まで控除できる
+		{newBox(), 5000.0, true},
+		{newBox(), 5000.5, false},
+		{newBox(), -1.0, false},
+		// 正常仕損しかなければ異常仕損費から控除できない
+		{totalcostingtest.Solved(totalcostingtest.DefectBox(totalcosting.AVG, totalcosting.Neglecting)), 5000.0, false},
+		{totalcostingtest.Solved(totalcostingtest.DefectBox(totalcosting.AVG, totalcosting.Neglecting)), 0.0, true},
+	}
+
+	for _, testCase := range testCases {
+		entries, err := NewGenerator().Generate(Input{Box: testCase.Box, ScrapValue: testCase.ScrapValue})
+		if (err == nil) != testCase.Result || (err != nil && !errors.Is(err, ErrScrapValue)) {
+			t.Errorf("Invalid result. testCase:%#v, actual:%v", testCase, err)
+			continue
+		}
+
+		// 負の金額の行は作らない
+		for _, e := range entries {
+			for _, l := range append(e.Debits, e.Credits...) {
+				if l.Amount < 0 {
+					t.Errorf("Invalid result. testCase:%#v, actual:%#v", testCase, e)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	entries := []Entry{
+		{
+			Description: "材料の消費",
+			Debits: []Line{
+				{DefaultAccounts().WIP, 30000.0},
+				{DefaultAccounts().Overhead, 2000.0},
+			},
+			Credits: []Line{
+				{DefaultAccounts().Material, 32000.0},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteCSV(&buf, "2021-04-30", entries))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, CSVHeader, records[0])
+	assert.Equal(t, []string{"1", "2021-04-30", "一般", "112", "仕掛品", "30000", "111", "材料", "32000", "材料の消費"}, records[1])
+	assert.Equal(t, []string{"1", "2021-04-30", "一般", "511", "製造間接費", "2000", "", "", "", "材料の消費"}, records[2])
+}
@@ -0,0 +1,159 @@
+package laborcosting
+
+import (
+	"fmt"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// WorkerType は工員の種別を表す
+type WorkerType int
+
+// 工員の種別(直接工, 間接工)
+const (
+	DirectWorker WorkerType = iota
+	IndirectWorker
+)
+
+// RateMethod は実際賃率の計算方法を表す
+type RateMethod int
+
+// 実際賃率の計算方法(個別賃率 or 平均賃率)
+const (
+	Individual RateMethod = iota
+	Average
+)
+
+// Worker is 工員1人分の支払と作業時間の記録
+type Worker struct {
+	Name          string
+	Type          WorkerType
+	Paid          float64 // 当月支払額
+	PrevAccrued   float64 // 前月未払
+	CurrAccrued   float64 // 当月未払
+	DirectHours   float64 // 直接作業時間
+	IndirectHours float64 // 間接作業時間
+	IdleHours     float64 // 手待時間
+}
+
+// ActualWage is 当月の要支払額(当月支払 - 前月未払 + 当月未払)を返す
+func (w Worker) ActualWage() float64 {
+	return w.Paid - w.PrevAccrued + w.CurrAccrued
+}
+
+// WorkingHours is 就業時間(直接作業時間 + 間接作業時間 + 手待時間)を返す
+func (w Worker) WorkingHours() float64 {
+	return w.DirectHours + w.IndirectHours + w.IdleHours
+}
+
+// Calculation is 労務費の計算
+type Calculation struct {
+	Method  RateMethod
+	Workers []Worker
+
+	// 予定賃率
+	// 0なら実際賃率で消費額を計算する
+	PredeterminedRate float64
+
+	ActualCost   float64 // 実際消費額(要支払額合計)
+	DirectCost   float64 // 直接労務費
+	IndirectCost float64 // 間接労務費
+	IdleCost     float64 // 手待賃金(間接労務費の内訳)
+	RateVariance float64 // 賃率差異(予定消費額 - 実際消費額)
+}
+
+// IsPredetermined is 予定賃率を使うか判定
+func (c Calculation) IsPredetermined() bool {
+	return c.PredeterminedRate > 0
+}
+
+// IsFavorable is 賃率差異が有利差異(貸方差異)かを返す
+func (c Calculation) IsFavorable() bool {
+	return c.RateVariance >= 0
+}
+
+// GetAverageRate is 直接工の実際平均賃率を返す
+func (c Calculation) GetAverageRate() float64 {
+	wage := 0.0
+	hours := 0.0
+
+	for _, w := range c.Workers {
+		if w.Type != DirectWorker {
+			continue
+		}
+
+		wage += w.ActualWage()
+		hours += w.WorkingHours()
+	}
+
+	if hours == 0 {
+		return 0.0
+	}
+
+	return wage / hours
+}
+
+// GetRate is 工員に適用する消費賃率を返す
+func (c Calculation) GetRate(w Worker) float64 {
+	if c.IsPredetermined() {
+		return c.PredeterminedRate
+	}
+
+	if c.Method == Average {
+		return c.GetAverageRate()
+	}
+
+	if w.WorkingHours() == 0 {
+		return 0.0
+	}
+
+	return w.ActualWage() / w.WorkingHours()
+}
+
+// Run is 直接労務費と間接労務費を計算する
+func (c *Calculation) Run() error {
+	c.ActualCost = 0
+	c.DirectCost = 0
+	c.IndirectCost = 0
+	c.IdleCost = 0
+	c.RateVariance = 0
+
+	for i, w := range c.Workers {
+		if w.DirectHours < 0 || w.IndirectHours < 0 || w.IdleHours < 0 {
+			return fmt.Errorf("laborcosting: workers[%d]: negative hours", i)
+		}
+
+		c.ActualCost += w.ActualWage()
+
+		// 間接工の賃金はすべて間接労務費
+		if w.Type == IndirectWorker {
+			c.IndirectCost += w.ActualWage()
+			continue
+		}
+
+		if w.WorkingHours() == 0 && w.ActualWage() != 0 {
+			return fmt.Errorf("laborcosting: workers[%d]: wage without working hours", i)
+		}
+
+		rate := c.GetRate(w)
+		direct := rate * w.DirectHours
+		idle := rate * w.IdleHours
+		indirect := rate*w.IndirectHours + idle
+
+		c.DirectCost += direct
+		c.IndirectCost += indirect
+		c.IdleCost += idle
+
+		// 予定賃率の場合は実際消費額との差額が賃率差異になる
+		if c.IsPredetermined() {
+			c.RateVariance += direct + indirect - w.ActualWage()
+		}
+	}
+
+	return nil
+}
+
+// AddTo is 直接労務費をCostの当月投入原価に加算する
+func (c Calculation) AddTo(cost *totalcosting.Cost) {
+	cost.InputCost += c.DirectCost
+}
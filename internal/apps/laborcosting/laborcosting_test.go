@@ -0,0 +1,98 @@
+package laborcosting
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWorkers() []Worker {
+	return []Worker{
+		{
+			Name:          "A",
+			Type:          DirectWorker,
+			Paid:          300000.0,
+			PrevAccrued:   50000.0,
+			CurrAccrued:   70000.0,
+			DirectHours:   150,
+			IndirectHours: 30,
+			IdleHours:     20,
+		},
+		{
+			Name:          "B",
+			Type:          DirectWorker,
+			Paid:          200000.0,
+			CurrAccrued:   40000.0,
+			DirectHours:   100,
+			IndirectHours: 20,
+		},
+		{
+			Name: "C",
+			Type: IndirectWorker,
+			Paid: 100000.0,
+		},
+	}
+}
+
+func TestActualWage(t *testing.T) {
+	testCases := []struct {
+		W      Worker
+		Result float64
+	}{
+		{Worker{Paid: 300000.0, PrevAccrued: 50000.0, CurrAccrued: 70000.0}, 320000.0},
+		{Worker{Paid: 200000.0}, 200000.0},
+	}
+
+	for _, testCase := range testCases {
+		result := testCase.W.ActualWage()
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%f", testCase, result)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		Method            RateMethod
+		PredeterminedRate float64
+		DirectCost        float64
+		IndirectCost      float64
+		RateVariance      float64
+	}{
+		{Individual, 0.0, 440000.0, 220000.0, 0.0},
+		{Average, 0.0, 437500.0, 222500.0, 0.0},
+		{Individual, 1800.0, 450000.0, 226000.0, 16000.0},
+	}
+
+	for _, testCase := range testCases {
+		calc := Calculation{
+			Method:            testCase.Method,
+			PredeterminedRate: testCase.PredeterminedRate,
+			Workers:           newWorkers(),
+		}
+
+		err := calc.Run()
+		assert.NoError(t, err)
+		assert.Equal(t, 660000.0, calc.ActualCost)
+		assert.Equal(t, testCase.DirectCost, calc.DirectCost)
+		assert.Equal(t, testCase.IndirectCost, calc.IndirectCost)
+		assert.Equal(t, testCase.RateVariance, calc.RateVariance)
+	}
+}
+
+func TestRunIdleCost(t *testing.T) {
+	calc := Calculation{Workers: newWorkers()}
+
+	assert.NoError(t, calc.Run())
+	assert.Equal(t, 32000.0, calc.IdleCost)
+}
+
+func TestAddTo(t *testing.T) {
+	calc := Calculation{Workers: newWorkers()}
+	assert.NoError(t, calc.Run())
+
+	processing := totalcosting.Cost{InputCost: 100000.0}
+	calc.AddTo(&processing)
+	assert.Equal(t, 540000.0, processing.InputCost)
+}
@@ -0,0 +1,387 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/journal"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// 記帳で発生するエラー
+var (
+	ErrUnbalanced   = errors.New("ledger: entry is not balanced")
+	ErrBookMismatch = errors.New("ledger: entry belongs to another book")
+)
+
+// OpeningDescription is 前月繰越の摘要
+const OpeningDescription = "前月繰越"
+
+// Posting is 勘定への転記1件
+type Posting struct {
+	Entry       int    // 仕訳番号(前月繰越は0)
+	Description string // 摘要
+	Counter     string // 相手勘定(複数なら諸口)
+	Amount      float64
+}
+
+// TAccount is 勘定(T字勘定)
+type TAccount struct {
+	Account journal.Account
+	Debits  []Posting
+	Credits []Posting
+}
+
+// DebitTotal is 借方合計を返す
+func (t TAccount) DebitTotal() float64 {
+	return total(t.Debits)
+}
+
+// CreditTotal is 貸方合計を返す
+func (t TAccount) CreditTotal() float64 {
+	return total(t.Credits)
+}
+
+// Balance is 残高を返す
+// 借方残高なら正, 貸方残高なら負
+func (t TAccount) Balance() float64 {
+	return t.DebitTotal() - t.CreditTotal()
+}
+
+// String is T字勘定をテキストで返す
+func (t TAccount) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s (%s)\n", t.Account.Name, t.Account.Code)
+
+	rows := len(t.Debits)
+	if len(t.Credits) > rows {
+		rows = len(t.Credits)
+	}
+
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&sb, "%-24s | %s\n", postingText(t.Debits, i), postingText(t.Credits, i))
+	}
+
+	fmt.Fprintf(&sb, "%-24s | %s\n",
+		"計 "+costreport.FormatYen(t.DebitTotal()),
+		"計 "+costreport.FormatYen(t.CreditTotal()))
+
+	return sb.String()
+}
+
+// Ledger is 総勘定元帳
+type Ledger struct {
+	Book     journal.Book
+	Entries  []journal.Entry
+	accounts map[string]*TAccount
+	order    []string
+}
+
+// New is bookの総勘定元帳を返す
+func New(book journal.Book) *Ledger {
+	return &Ledger{
+		Book:     book,
+		accounts: make(map[string]*TAccount),
+	}
+}
+
+// Open is 前月繰越を記帳する
+// amountは借方残高なら正, 貸方残高なら負
+func (l *Ledger) Open(account journal.Account, amount float64) {
+	t := l.get(account)
+	posting := Posting{Description: OpeningDescription, Counter: OpeningDescription}
+
+	if amount >= 0 {
+		posting.Amount = amount
+		t.Debits = append(t.Debits, posting)
+	} else {
+		posting.Amount = -amount
+		t.Credits = append(t.Credits, posting)
+	}
+}
+
+// Post is 仕訳を勘定に転記する
+func (l *Ledger) Post(e journal.Entry) error {
+	if e.Book != l.Book {
+		return ErrBookMismatch
+	}
+	if !e.IsBalanced() {
+		return fmt.Errorf("%w: %s", ErrUnbalanced, e.Description)
+	}
+
+	l.Entries = append(l.Entries, e)
+	number := len(l.Entries)
+
+	for _, d := range e.Debits {
+		t := l.get(d.Account)
+		t.Debits = append(t.Debits, Posting{number, e.Description, counter(e.Credits), d.Amount})
+	}
+	for _, c := range e.Credits {
+		t := l.get(c.Account)
+		t.Credits = append(t.Credits, Posting{number, e.Description, counter(e.Debits), c.Amount})
+	}
+
+	return nil
+}
+
+// PostAll is 仕訳をまとめて転記する
+// 途中でエラーがあればそこで止まる
+func (l *Ledger) PostAll(entries []journal.Entry) error {
+	for _, e := range entries {
+		if err := l.Post(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Account is 勘定を返す
+// 転記がなければ空の勘定を返す
+func (l *Ledger) Account(account journal.Account) TAccount {
+	if t, ok := l.accounts[account.Code]; ok {
+		return *t
+	}
+
+	return TAccount{Account: account}
+}
+
+// Accounts is 記帳順に勘定を返す
+func (l *Ledger) Accounts() []TAccount {
+	accounts := make([]TAccount, len(l.order))
+
+	for i, code := range l.order {
+		accounts[i] = *l.accounts[code]
+	}
+
+	return accounts
+}
+
+// get is 勘定を返す
+// なければ作る
+func (l *Ledger) get(account journal.Account) *TAccount {
+	if t, ok := l.accounts[account.Code]; ok {
+		return t
+	}
+
+	t := &TAccount{Account: account}
+	l.accounts[account.Code] = t
+	l.order = append(l.order, account.Code)
+
+	return t
+}
+
+// TrialBalanceRow is 試算表の1行
+type TrialBalanceRow struct {
+	Account       journal.Account
+	DebitTotal    float64 // 借方合計
+	CreditTotal   float64 // 貸方合計
+	DebitBalance  float64 // 借方残高
+	CreditBalance float64 // 貸方残高
+}
+
+// TrialBalance is 合計残高試算表
+type TrialBalance struct {
+	Rows []TrialBalanceRow
+}
+
+// TrialBalance is 合計残高試算表を作る
+func (l *Ledger) TrialBalance() TrialBalance {
+	var tb TrialBalance
+
+	for _, t := range l.Accounts() {
+		row := TrialBalanceRow{
+			Account:     t.Account,
+			DebitTotal:  t.DebitTotal(),
+			CreditTotal: t.CreditTotal(),
+		}
+
+		if balance := t.Balance(); balance >= 0 {
+			row.DebitBalance = balance
+		} else {
+			row.CreditBalance = -balance
+		}
+
+		tb.Rows = append(tb.Rows, row)
+	}
+
+	return tb
+}
+
+// Totals is 借方合計, 貸方合計, 借方残高, 貸方残高の総計を返す
+func (tb TrialBalance) Totals() TrialBalanceRow {
+	var totals TrialBalanceRow
+
+	for _, r := range tb.Rows {
+		totals.DebitTotal += r.DebitTotal
+		totals.CreditTotal += r.CreditTotal
+		totals.DebitBalance += r.DebitBalance
+		totals.CreditBalance += r.CreditBalance
+	}
+
+	return totals
+}
+
+// IsBalanced is 試算表の貸借が一致しているか判定
+func (tb TrialBalance) IsBalanced() bool {
+	totals := tb.Totals()
+
+	return math.Abs(totals.DebitTotal-totals.CreditTotal) < 1e-6 &&
+		math.Abs(totals.DebitBalance-totals.CreditBalance) < 1e-6
+}
+
+// String is 試算表をテキストで返す
+func (tb TrialBalance) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("合計残高試算表\n")
+	fmt.Fprintf(&sb, "%12s %12s  %-12s %12s %12s\n", "借方残高", "借方合計", "勘定科目", "貸方合計", "貸方残高")
+
+	totals := tb.Totals()
+	totals.Account.Name = "合計"
+	rows := make([]TrialBalanceRow, 0, len(tb.Rows)+1)
+	rows = append(rows, tb.Rows...)
+	rows = append(rows, totals)
+
+	for _, r := range rows {
+		fmt.Fprintf(&sb, "%12s %12s  %-12s %12s %12s\n",
+			costreport.FormatYen(r.DebitBalance),
+			costreport.FormatYen(r.DebitTotal),
+			r.Account.Name,
+			costreport.FormatYen(r.CreditTotal),
+			costreport.FormatYen(r.CreditBalance))
+	}
+
+	return sb.String()
+}
+
+// ReconcileBoxes is Boxの物量と金額が仕掛品勘定と一致しているか確認する
+// BoxesはRun済みで, 仕掛品勘定の前月繰越は記帳済みであること
+// 一致しない項目をまとめたエラーを返す
+func (l *Ledger) ReconcileBoxes(wip journal.Account, boxes []totalcosting.Box) error {
+	return l.reconcile(wip, boxes, false)
+}
+
+// ReconcileSequential is 工程順に並んだ連続工程のBoxが1つの仕掛品勘定と一致しているか確認する
+// 前工程の完成品原価は仕掛品勘定の中で次工程の前工程費に振り替えられるので,
+// 当月投入から除き, 完成品は最終工程の分だけを数える
+func (l *Ledger) ReconcileSequential(wip journal.Account, boxes []totalcosting.Box) error {
+	return l.reconcile(wip, boxes, true)
+}
+
+// reconcile is ReconcileBoxesとReconcileSequentialの共通処理
+func (l *Ledger) reconcile(wip journal.Account, boxes []totalcosting.Box, sequential bool) error {
+	var problems []string
+	first, input, output, last := 0.0, 0.0, 0.0, 0.0
+
+	for i, b := range boxes {
+		left, right := physicalFlow(b.Master)
+		if left != right {
+			problems = append(problems, fmt.Sprintf("boxes[%d]: physical flow %d != %d", i, left, right))
+		}
+
+		for _, c := range b.Costs {
+			first += c.FirstCost
+			input += c.InputCost
+		}
+		output += journal.GetAbnormalCost(b)
+		last += b.EOTMTotalCost
+
+		if !sequential || i == len(boxes)-1 {
+			output += b.ProductTotalCost
+		}
+		if sequential && i > 0 {
+			input -= boxes[i-1].ProductTotalCost
+		}
+	}
+
+	t := l.Account(wip)
+	opening := 0.0
+	debit := 0.0
+	for _, p := range t.Debits {
+		if p.Description == OpeningDescription {
+			opening += p.Amount
+		} else {
+			debit += p.Amount
+		}
+	}
+
+	check := func(name string, box float64, account float64) {
+		if math.Abs(box-account) >= 1.0 {
+			problems = append(problems, fmt.Sprintf("%s: box %s, account %s",
+				name, costreport.FormatYen(box), costreport.FormatYen(account)))
+		}
+	}
+	check("月初仕掛品", first, opening)
+	check("当月投入", input, debit)
+	check("完成品・異常仕損", output, t.CreditTotal())
+	check("月末仕掛品", last, t.Balance())
+
+	if len(problems) > 0 {
+		return fmt.Errorf("ledger: %s does not reconcile: %s", wip.Name, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// physicalFlow is Box図の左側と右側の数量合計を返す
+func physicalFlow(master []totalcosting.Element) (int, int) {
+	left, right := 0, 0
+
+	for _, e := range master {
+		if e.IsLeftElement() {
+			left += e.Unit
+		} else {
+			right += e.Unit
+		}
+	}
+
+	return left, right
+}
+
+// Select is bookの仕訳だけを返す
+func Select(entries []journal.Entry, book journal.Book) []journal.Entry {
+	var result []journal.Entry
+
+	for _, e := range entries {
+		if e.Book == book {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// counter is 相手勘定の名前を返す
+// 複数あれば諸口
+func counter(lines []journal.Line) string {
+	if len(lines) == 1 {
+		return lines[0].Account.Name
+	}
+
+	return "諸口"
+}
+
+// postingText is i番目の転記の表示を返す
+func postingText(postings []Posting, i int) string {
+	if i >= len(postings) {
+		return ""
+	}
+
+	return fmt.Sprintf("%s %s", postings[i].Counter, costreport.FormatYen(postings[i].Amount))
+}
+
+// total is 転記の金額合計を返す
+func total(postings []Posting) float64 {
+	sum := 0.0
+
+	for _, p := range postings {
+		sum += p.Amount
+	}
+
+	return sum
+}
@@ -0,0 +1,152 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/journal"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/laborcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/materialcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLedger(t *testing.T) (*Ledger, totalcosting.Box) {
+	box := totalcostingtest.Solved(totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting))
+	accounts := journal.DefaultAccounts()
+
+	entries, err := journal.NewGenerator().Generate(journal.Input{
+		Box:      box,
+		Material: &materialcosting.Ledger{DirectCost: 717600},
+		Labor:    &laborcosting.Calculation{DirectCost: 600000, IndirectCost: 372360},
+		SoldCost: 1500000,
+	})
+	assert.NoError(t, err)
+
+	l := New(journal.Unified)
+	l.Open(accounts.WIP, 368040)
+	l.Open(journal.Account{Code: "411", Name: "資本金"}, -368040)
+	assert.NoError(t, l.PostAll(entries))
+
+	return l, box
+}
+
+func TestPost(t *testing.T) {
+	l, _ := newLedger(t)
+	wip := l.Account(journal.DefaultAccounts().WIP)
+
+	assert.Equal(t, 4, len(wip.Debits))
+	assert.Equal(t, 1, len(wip.Credits))
+	assert.Equal(t, "製品", wip.Credits[0].Counter)
+	assert.Equal(t, 186000.0, wip.Balance())
+	assert.Equal(t, 0.0, l.Account(journal.DefaultAccounts().Overhead).Balance())
+}
+
+func TestPostError(t *testing.T) {
+	l := New(journal.Unified)
+
+	err := l.Post(journal.Entry{Book: journal.Factory})
+	assert.ErrorIs(t, err, ErrBookMismatch)
+
+	err = l.Post(journal.Entry{
+		Debits: []journal.Line{{Account: journal.DefaultAccounts().WIP, Amount: 100}},
+	})
+	assert.ErrorIs(t, err, ErrUnbalanced)
+	assert.Equal(t, 0, len(l.Entries))
+}
+
+func TestCostOfSales(t *testing.T) {
+	l, _ := newLedger(t)
+	accounts := journal.DefaultAccounts()
+
+	product := l.Account(accounts.Product)
+	assert.Equal(t, 1872000.0-1500000.0, product.Balance())
+	assert.Equal(t, "売上原価", product.Credits[0].Counter)
+
+	cogs := l.Account(accounts.CostOfSales)
+	assert.Equal(t, 1500000.0, cogs.Balance())
+	assert.Equal(t, "製品", cogs.Debits[0].Counter)
+}
+
+func TestTrialBalance(t *testing.T) {
+	l, _ := newLedger(t)
+	tb := l.TrialBalance()
+
+	assert.True(t, tb.IsBalanced())
+	assert.Equal(t, 186000.0+1872000.0, tb.Totals().DebitBalance)
+	assert.True(t, strings.HasPrefix(tb.String(), "合計残高試算表\n"))
+}
+
+func TestReconcileBoxes(t *testing.T) {
+	l, box := newLedger(t)
+	wip := journal.DefaultAccounts().WIP
+
+	assert.NoError(t, l.ReconcileBoxes(wip, []totalcosting.Box{box}))
+
+	box.Master[1].Unit = 1000
+	box.EOTMTotalCost = 0
+	err := l.ReconcileBoxes(wip, []totalcosting.Box{box})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "physical flow")
+	assert.Contains(t, err.Error(), "月末仕掛品")
+}
+
+func TestReconcileSequential(t *testing.T) {
+	first := totalcostingtest.Solved(totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting))
+
+	// 第1工程の完成品原価を前工程費として投入する
+	second := totalcostingtest.Solved(totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.Input, Unit: 1440},
+			{Type: totalcosting.Output, Unit: 1200},
+			{Type: totalcosting.Last, Unit: 240, Progress: 0.5},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "前工程費", CMethod: totalcosting.AVG, DMethod: totalcosting.NonNeglecting, InputCost: first.ProductTotalCost},
+			{Name: "加工費", InputOnAvg: true, CMethod: totalcosting.AVG, DMethod: totalcosting.NonNeglecting, InputCost: 132000},
+		},
+	})
+
+	// 1つの仕掛品勘定で記帳し, 最終工程の完成品だけを製品に振り替える
+	entries, err := journal.NewGenerator().Generate(journal.Input{
+		Box:      second,
+		Material: &materialcosting.Ledger{DirectCost: 717600},
+		Labor:    &laborcosting.Calculation{DirectCost: 600000 + 132000, IndirectCost: 372360},
+	})
+	assert.NoError(t, err)
+
+	accounts := journal.DefaultAccounts()
+	l := New(journal.Unified)
+	l.Open(accounts.WIP, 368040)
+	l.Open(journal.Account{Code: "411", Name: "資本金"}, -368040)
+	assert.NoError(t, l.PostAll(entries))
+
+	boxes := []totalcosting.Box{first, second}
+	assert.InDelta(t, first.EOTMTotalCost+second.EOTMTotalCost, l.Account(accounts.WIP).Balance(), 1e-6)
+	assert.NoError(t, l.ReconcileSequential(accounts.WIP, boxes))
+
+	// 並列工程として照合すると前工程費と第1工程の完成品を二重に数える
+	err = l.ReconcileBoxes(accounts.WIP, boxes)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "当月投入")
+	assert.Contains(t, err.Error(), "完成品・異常仕損")
+}
+
+func TestSelect(t *testing.T) {
+	entries := []journal.Entry{
+		{Book: journal.HeadOffice},
+		{Book: journal.Factory},
+		{Book: journal.Factory},
+	}
+
+	assert.Equal(t, 2, len(Select(entries, journal.Factory)))
+}
+
+func TestTAccountString(t *testing.T) {
+	l, _ := newLedger(t)
+	s := l.Account(journal.DefaultAccounts().WIP).String()
+
+	assert.True(t, strings.HasPrefix(s, "仕掛品 (112)\n"))
+	assert.Contains(t, s, "前月繰越 368,040")
+}
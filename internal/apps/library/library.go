@@ -0,0 +1,422 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// ErrNotFound is 指定したIDの問題がない
+var ErrNotFound = errors.New("library: problem not found")
+
+// counterName is 次に使うIDを保存するファイル名
+// 削除した問題のIDを使い回さないように保存しておく
+const counterName = "next"
+
+// 一覧の既定と上限の件数
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Problem is 問題集の問題1つ
+type Problem struct {
+	ID        string           `json:"id"`
+	Title     string           `json:"title"`
+	Statement string           `json:"statement,omitempty"` // 問題文
+	Tags      []string         `json:"tags,omitempty"`
+	Box       totalcosting.Box `json:"box"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// Query is 一覧の絞り込みとページ
+type Query struct {
+	Tags   []string // すべてのタグを持つ問題に絞る
+	Text   string   // タイトルか問題文に含む問題に絞る(大文字と小文字は区別しない)
+	Offset int
+	Limit  int // 0ならDefaultLimit
+}
+
+// Page is 一覧の1ページ
+type Page struct {
+	Problems []Problem `json:"problems"`
+	Total    int       `json:"total"` // 絞り込んだ問題の総数
+	Offset   int       `json:"offset"`
+	Limit    int       `json:"limit"`
+}
+
+// Store is ディレクトリに問題を1つずつJSONファイルで保存する問題集
+type Store struct {
+	dir string
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+// Open is ディレクトリの問題集を開く
+// ディレクトリがなければ作る
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("library: %w", err)
+	}
+
+	return &Store{dir: dir, now: time.Now}, nil
+}
+
+// Create is 問題を追加してIDと日時を付けた問題を返す
+func (s *Store) Create(p Problem) (Problem, error) {
+	if err := validate(p); err != nil {
+		return Problem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	problems, err := s.all()
+	if err != nil {
+		return Problem{}, err
+	}
+
+	// IDは連番で, 削除した問題のIDも使わない
+	next, err := s.next(problems)
+	if err != nil {
+		return Problem{}, err
+	}
+	counter := filepath.Join(s.dir, counterName)
+	if err := writeFile(s.dir, counter, []byte(strconv.Itoa(next+1)+"\n")); err != nil {
+		return Problem{}, err
+	}
+
+	p.ID = strconv.Itoa(next)
+	p.Box = problemBox(p.Box)
+	p.Tags = normalizeTags(p.Tags)
+	p.CreatedAt = s.now().UTC()
+	p.UpdatedAt = p.CreatedAt
+
+	if err := s.write(p); err != nil {
+		return Problem{}, err
+	}
+
+	return p, nil
+}
+
+// Get is IDの問題を返す
+func (s *Store) Get(id string) (Problem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(id)
+}
+
+// Update is IDの問題を書き換えて返す
+// 作成日時は元の問題のものを残す
+func (s *Store) Update(id string, p Problem) (Problem, error) {
+	if err := validate(p); err != nil {
+		return Problem{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.read(id)
+	if err != nil {
+		return Problem{}, err
+	}
+
+	p.ID = old.ID
+	p.Box = problemBox(p.Box)
+	p.Tags = normalizeTags(p.Tags)
+	p.CreatedAt = old.CreatedAt
+	p.UpdatedAt = s.now().UTC()
+
+	if err := s.write(p); err != nil {
+		return Problem{}, err
+	}
+
+	return p, nil
+}
+
+// Delete is IDの問題を削除する
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, ok := s.path(id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err := os.Remove(name); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("library: %w", err)
+	}
+
+	return nil
+}
+
+// List is 絞り込んだ問題をIDの順に並べて1ページ分返す
+func (s *Store) List(q Query) (Page, error) {
+	s.mu.Lock()
+	problems, err := s.all()
+	s.mu.Unlock()
+	if err != nil {
+		return Page{}, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := Page{Problems: []Problem{}, Offset: offset, Limit: limit}
+	for _, p := range problems {
+		if !q.match(p) {
+			continue
+		}
+
+		if page.Total >= offset && len(page.Problems) < limit {
+			page.Problems = append(page.Problems, p)
+		}
+		page.Total++
+	}
+
+	return page, nil
+}
+
+// match is 問題が絞り込みの条件に合うか判定
+func (q Query) match(p Problem) bool {
+	for _, tag := range q.Tags {
+		found := false
+		for _, t := range p.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+	if text == "" {
+		return true
+	}
+
+	return strings.Contains(strings.ToLower(p.Title), text) ||
+		strings.Contains(strings.ToLower(p.Statement), text)
+}
+
+// all is すべての問題をIDの順に返す
+func (s *Store) all() ([]Problem, error) {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("library: %w", err)
+	}
+
+	var problems []Problem
+	for _, name := range names {
+		p, err := readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, p)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, errA := strconv.Atoi(problems[i].ID)
+		b, errB := strconv.Atoi(problems[j].ID)
+		if errA != nil || errB != nil {
+			return problems[i].ID < problems[j].ID
+		}
+		return a < b
+	})
+
+	return problems, nil
+}
+
+// next is 次に使うIDを返す
+// 保存したIDと既存の問題の最大のIDの次のうち大きい方を使う
+func (s *Store) next(problems []Problem) (int, error) {
+	next := 1
+	for _, q := range problems {
+		if n, err := strconv.Atoi(q.ID); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, counterName))
+	if errors.Is(err, os.ErrNotExist) {
+		return next, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("library: %w", err)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("library: %s: %w", counterName, err)
+	}
+	if n > next {
+		next = n
+	}
+
+	return next, nil
+}
+
+// read is IDの問題のファイルを読む
+func (s *Store) read(id string) (Problem, error) {
+	name, ok := s.path(id)
+	if !ok {
+		return Problem{}, ErrNotFound
+	}
+
+	p, err := readFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return Problem{}, ErrNotFound
+	}
+
+	return p, err
+}
+
+// write is 問題をファイルに書く
+func (s *Store) write(p Problem) error {
+	name, ok := s.path(p.ID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	return writeFile(s.dir, name, append(data, '\n'))
+}
+
+// writeFile is dataをnameのファイルに書く
+// 書きかけのファイルが残らないようにdirの一時ファイルに書いてから置き換える
+func writeFile(dir string, name string, data []byte) error {
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("library: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	return nil
+}
+
+// path is IDの問題のファイル名を返す
+// ディレクトリの外を指すIDは受け付けない
+func (s *Store) path(id string) (string, bool) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", false
+	}
+
+	return filepath.Join(s.dir, id+".json"), true
+}
+
+// readFile is 問題のファイルを読む
+func readFile(name string) (Problem, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Problem{}, fmt.Errorf("library: %w", err)
+	}
+	defer f.Close()
+
+	var p Problem
+	if err := json.NewDecoder(f).Decode(&p); err != nil {
+		return Problem{}, fmt.Errorf("library: %s: %w", filepath.Base(name), err)
+	}
+
+	return p, nil
+}
+
+// validate is 保存できる問題か確認する
+// 問題があればtotalcosting.ValidationErrorを返す
+func validate(p Problem) error {
+	var errs totalcosting.ValidationError
+
+	if strings.TrimSpace(p.Title) == "" {
+		errs = append(errs, totalcosting.FieldError{Field: "title", Message: "is required"})
+	}
+
+	if err := p.Box.Validate(); err != nil {
+		v, ok := err.(totalcosting.ValidationError)
+		if !ok {
+			return err
+		}
+		for _, e := range v {
+			errs = append(errs, totalcosting.FieldError{Field: "box." + e.Field, Message: e.Message})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// problemBox is 計算結果を取り除いて問題だけのBoxにする
+func problemBox(box totalcosting.Box) totalcosting.Box {
+	result := totalcosting.Box{
+		Master: box.Master,
+		Costs:  make([]totalcosting.Cost, len(box.Costs)),
+	}
+
+	for i, c := range box.Costs {
+		c.Elements = nil
+		result.Costs[i] = c
+	}
+
+	return result
+}
+
+// normalizeTags is タグの前後の空白と空のタグ, 重複を取り除く
+func normalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		result = append(result, t)
+	}
+
+	return result
+}
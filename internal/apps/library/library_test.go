@@ -0,0 +1,176 @@
+package library
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestStore is 一時ディレクトリの問題集
+func newTestStore(t *testing.T) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "problems"))
+	assert.NoError(t, err)
+
+	now := time.Date(2020, 4, 1, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	return s
+}
+
+func TestCRUD(t *testing.T) {
+	s := newTestStore(t)
+
+	box := totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting)
+	box.Run()
+	p, err := s.Create(Problem{Title: "平均法", Tags: []string{" 平均法 ", "", "平均法"}, Box: box})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", p.ID)
+	assert.Equal(t, []string{"平均法"}, p.Tags)
+	assert.Empty(t, p.Box.Trace)
+	assert.Nil(t, p.Box.Costs[0].Elements)
+
+	got, err := s.Get("1")
+	assert.NoError(t, err)
+	assert.Equal(t, p, got)
+
+	// 保存した問題はBoxのJSONとして読める
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, "1.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"calculation_method": "AVG"`)
+
+	p.Title = "平均法(改)"
+	updated, err := s.Update("1", p)
+	assert.NoError(t, err)
+	assert.Equal(t, "平均法(改)", updated.Title)
+	assert.Equal(t, p.CreatedAt, updated.CreatedAt)
+	assert.True(t, updated.UpdatedAt.After(p.UpdatedAt))
+
+	p2, err := s.Create(Problem{Title: "2問目", Box: totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting)})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", p2.ID)
+
+	assert.NoError(t, s.Delete("1"))
+	_, err = s.Get("1")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, ErrNotFound, s.Delete("1"))
+	_, err = s.Update("1", p)
+	assert.Equal(t, ErrNotFound, err)
+
+	// 番号は残っている問題の最大の次
+	p3, err := s.Create(Problem{Title: "3問目", Box: totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting)})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", p3.ID)
+}
+
+func TestCreateNotReuseID(t *testing.T) {
+	s := newTestStore(t)
+	box := totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting)
+
+	for _, id := range []string{"1", "2"} {
+		p, err := s.Create(Problem{Title: id, Box: box})
+		assert.NoError(t, err)
+		assert.Equal(t, id, p.ID)
+	}
+
+	// 最新の問題を削除しても番号は使い回さない
+	assert.NoError(t, s.Delete("2"))
+	p, err := s.Create(Problem{Title: "3", Box: box})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", p.ID)
+
+	// 開き直しても続きの番号になる
+	assert.NoError(t, s.Delete("3"))
+	reopened, err := Open(s.dir)
+	assert.NoError(t, err)
+	p, err = reopened.Create(Problem{Title: "4", Box: box})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", p.ID)
+
+	// 番号のファイルがない問題集は既存の問題の最大の次
+	assert.NoError(t, os.Remove(filepath.Join(s.dir, counterName)))
+	p, err = reopened.Create(Problem{Title: "5", Box: box})
+	assert.NoError(t, err)
+	assert.Equal(t, "5", p.ID)
+
+	// 番号のファイルは問題の一覧に含めない
+	page, err := reopened.List(Query{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+}
+
+func TestCreateError(t *testing.T) {
+	s := newTestStore(t)
+
+	_, err := s.Create(Problem{Box: totalcosting.Box{}})
+	errs, ok := err.(totalcosting.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, "title", errs[0].Field)
+	assert.Equal(t, "box.master", errs[1].Field)
+}
+
+func TestInvalidID(t *testing.T) {
+	s := newTestStore(t)
+
+	testCases := []string{"", "../1", "1.json", `a\b`}
+	for _, testCase := range testCases {
+		if _, err := s.Get(testCase); err != ErrNotFound {
+			t.Errorf("Invalid result. testCase:%#v, actual:%v", testCase, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	s := newTestStore(t)
+
+	for i := 1; i <= 12; i++ {
+		p := Problem{Title: "問題" + strconv.Itoa(i), Box: totalcostingtest.Box(totalcosting.AVG, totalcosting.NonNeglecting)}
+		if i%2 == 0 {
+			p.Tags = []string{"FIFO"}
+		}
+		if i%3 == 0 {
+			p.Tags = append(p.Tags, "度外視法")
+			p.Statement = "正常仕損は工程の終点で発生した。"
+		}
+		_, err := s.Create(p)
+		assert.NoError(t, err)
+	}
+
+	testCases := []struct {
+		Query Query
+		Total int
+		IDs   []string
+	}{
+		{Query{Limit: 3}, 12, []string{"1", "2", "3"}},
+		{Query{Offset: 10, Limit: 5}, 12, []string{"11", "12"}},
+		{Query{Tags: []string{"fifo"}, Limit: 2, Offset: 2}, 6, []string{"6", "8"}},
+		{Query{Tags: []string{"FIFO", "度外視法"}}, 2, []string{"6", "12"}},
+		{Query{Text: "終点"}, 4, []string{"3", "6", "9", "12"}},
+		{Query{Text: "問題1"}, 4, []string{"1", "10", "11", "12"}},
+		{Query{Text: "なし"}, 0, []string{}},
+	}
+
+	for _, testCase := range testCases {
+		page, err := s.List(testCase.Query)
+		ids := []string{}
+		for _, p := range page.Problems {
+			ids = append(ids, p.ID)
+		}
+		if err != nil || page.Total != testCase.Total || !assert.ObjectsAreEqual(testCase.IDs, ids) {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %v", testCase, page.Total, ids)
+		}
+	}
+
+	page, err := s.List(Query{Limit: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, MaxLimit, page.Limit)
+}
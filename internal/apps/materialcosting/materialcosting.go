@@ -0,0 +1,341 @@
+package materialcosting
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// PricingMethod は払出単価の計算方法を表す
+type PricingMethod int
+
+// 払出単価の計算方法(先入先出法, 移動平均法, 総平均法)
+const (
+	FIFO PricingMethod = iota
+	MovingAverage
+	PeriodicAverage
+)
+
+// EntryType は材料元帳への記帳の種別を表す
+type EntryType int
+
+// 記帳の種別(受入, 払出)
+const (
+	Receipt EntryType = iota
+	Issue
+)
+
+// ErrShortage は払出数量が帳簿残高を超えた場合のエラー
+var ErrShortage = errors.New("materialcosting: issue exceeds balance")
+
+// Entry is 材料の受入または払出1件
+type Entry struct {
+	Day    int       // 日付
+	Type   EntryType // 種別
+	Unit   int       // 数量
+	Price  float64   // 受入単価(受入のみ)
+	Direct bool      // 直接材料として消費したか(払出のみ)
+	Note   string    // 摘要
+}
+
+// Row is 材料元帳の1行
+type Row struct {
+	Day           int
+	Note          string
+	InUnit        int
+	InPrice       float64
+	InAmount      float64
+	OutUnit       int
+	OutPrice      float64
+	OutAmount     float64
+	BalanceUnit   int
+	BalanceAmount float64
+}
+
+// BalancePrice is 残高の平均単価を返す
+func (r Row) BalancePrice() float64 {
+	if r.BalanceUnit == 0 {
+		return 0.0
+	}
+
+	return r.BalanceAmount / float64(r.BalanceUnit)
+}
+
+// lot is 先入先出法で管理する受入単位
+type lot struct {
+	unit  int
+	price float64
+}
+
+// Ledger is 材料元帳
+type Ledger struct {
+	Method     PricingMethod
+	FirstUnit  int     // 月初有高の数量
+	FirstPrice float64 // 月初有高の単価
+	Entries    []Entry
+
+	Stocktaking    bool // 実地棚卸を行ったか
+	ActualLastUnit int  // 実地棚卸数量
+
+	// 予定消費価格
+	// 0なら実際消費価格で消費額を計算する
+	PredeterminedPrice float64
+
+	Rows           []Row
+	ActualCost     float64 // 実際消費額
+	DirectCost     float64 // 直接材料費
+	IndirectCost   float64 // 間接材料費
+	ShrinkageUnit  int     // 棚卸減耗数量
+	ShrinkageCost  float64 // 棚卸減耗費
+	PriceVariance  float64 // 材料消費価格差異(予定消費額 - 実際消費額)
+	LastUnit       int     // 月末有高の数量
+	LastCost       float64 // 月末有高
+	lots           []lot
+	balanceUnit    int
+	balanceAmount  float64
+	periodicPrice  float64
+	directUnit     int
+	indirectUnit   int
+	actualDirect   float64
+	actualIndirect float64
+}
+
+// IsPredetermined is 予定消費価格を使うか判定
+func (l Ledger) IsPredetermined() bool {
+	return l.PredeterminedPrice > 0
+}
+
+// IsFavorable is 材料消費価格差異が有利差異(貸方差異)かを返す
+func (l Ledger) IsFavorable() bool {
+	return l.PriceVariance >= 0
+}
+
+// AddTo is 当月の直接材料費をCostの当月投入原価に加算する
+func (l Ledger) AddTo(cost *totalcosting.Cost) {
+	cost.InputCost += l.DirectCost
+}
+
+// Run is 材料元帳を記帳して消費額を計算する
+func (l *Ledger) Run() error {
+	l.reset()
+
+	if l.Method == PeriodicAverage {
+		l.periodicPrice = l.getPeriodicPrice()
+	}
+
+	for i, e := range l.Entries {
+		if e.Unit < 0 {
+			return fmt.Errorf("materialcosting: entries[%d]: negative unit %d", i, e.Unit)
+		}
+
+		switch e.Type {
+		case Receipt:
+			l.receive(e)
+		case Issue:
+			cost, err := l.issue(e.Day, e.Note, e.Unit)
+			if err != nil {
+				return fmt.Errorf("materialcosting: entries[%d]: %w", i, err)
+			}
+
+			if e.Direct {
+				l.directUnit += e.Unit
+				l.actualDirect += cost
+			} else {
+				l.indirectUnit += e.Unit
+				l.actualIndirect += cost
+			}
+		default:
+			return fmt.Errorf("materialcosting: entries[%d]: unknown entry type %d", i, e.Type)
+		}
+	}
+
+	// 棚卸減耗の計算
+	if l.Stocktaking {
+		if l.ActualLastUnit > l.balanceUnit {
+			return fmt.Errorf("materialcosting: actual last unit %d exceeds book balance %d",
+				l.ActualLastUnit, l.balanceUnit)
+		}
+
+		l.ShrinkageUnit = l.balanceUnit - l.ActualLastUnit
+		if l.ShrinkageUnit > 0 {
+			cost, err := l.issue(lastDay(l.Entries), "棚卸減耗", l.ShrinkageUnit)
+			if err != nil {
+				return err
+			}
+			l.ShrinkageCost = cost
+		}
+	}
+
+	l.ActualCost = l.actualDirect + l.actualIndirect
+	l.LastUnit = l.balanceUnit
+	l.LastCost = l.balanceAmount
+
+	// 予定消費価格を使う場合は予定消費額で消費額を計上し差異を把握する
+	if l.IsPredetermined() {
+		l.DirectCost = l.PredeterminedPrice * float64(l.directUnit)
+		l.IndirectCost = l.PredeterminedPrice * float64(l.indirectUnit)
+		l.PriceVariance = l.DirectCost + l.IndirectCost - l.ActualCost
+	} else {
+		l.DirectCost = l.actualDirect
+		l.IndirectCost = l.actualIndirect
+	}
+
+	return nil
+}
+
+// reset is 前回の計算結果を消去して月初有高から記帳を始める
+func (l *Ledger) reset() {
+	l.Rows = nil
+	l.lots = nil
+	l.ActualCost = 0
+	l.DirectCost = 0
+	l.IndirectCost = 0
+	l.ShrinkageUnit = 0
+	l.ShrinkageCost = 0
+	l.PriceVariance = 0
+	l.directUnit = 0
+	l.indirectUnit = 0
+	l.actualDirect = 0
+	l.actualIndirect = 0
+
+	l.balanceUnit = l.FirstUnit
+	l.balanceAmount = l.FirstPrice * float64(l.FirstUnit)
+	if l.FirstUnit > 0 {
+		l.lots = append(l.lots, lot{unit: l.FirstUnit, price: l.FirstPrice})
+	}
+
+	l.Rows = append(l.Rows, Row{
+		Day:           1,
+		Note:          "前月繰越",
+		InUnit:        l.FirstUnit,
+		InPrice:       l.FirstPrice,
+		InAmount:      l.balanceAmount,
+		BalanceUnit:   l.balanceUnit,
+		BalanceAmount: l.balanceAmount,
+	})
+}
+
+// getPeriodicPrice is 総平均法の払出単価を返す
+func (l Ledger) getPeriodicPrice() float64 {
+	unit := l.FirstUnit
+	amount := l.FirstPrice * float64(l.FirstUnit)
+
+	for _, e := range l.Entries {
+		if e.Type == Receipt {
+			unit += e.Unit
+			amount += e.Price * float64(e.Unit)
+		}
+	}
+
+	if unit == 0 {
+		return 0.0
+	}
+
+	return amount / float64(unit)
+}
+
+// receive is 受入の記帳
+func (l *Ledger) receive(e Entry) {
+	amount := e.Price * float64(e.Unit)
+
+	l.balanceUnit += e.Unit
+	l.balanceAmount += amount
+	l.lots = append(l.lots, lot{unit: e.Unit, price: e.Price})
+
+	l.Rows = append(l.Rows, Row{
+		Day:           e.Day,
+		Note:          e.Note,
+		InUnit:        e.Unit,
+		InPrice:       e.Price,
+		InAmount:      amount,
+		BalanceUnit:   l.balanceUnit,
+		BalanceAmount: l.balanceAmount,
+	})
+}
+
+// issue is 払出の記帳
+// 払出額を返す
+func (l *Ledger) issue(day int, note string, unit int) (float64, error) {
+	if unit > l.balanceUnit {
+		return 0, ErrShortage
+	}
+	if unit == 0 {
+		return 0, nil
+	}
+
+	// 先入先出法は受入ごとに払出行を分ける
+	if l.Method == FIFO {
+		total := 0.0
+		rest := unit
+
+		for rest > 0 {
+			take := l.lots[0].unit
+			if take > rest {
+				take = rest
+			}
+
+			price := l.lots[0].price
+			amount := price * float64(take)
+			total += amount
+			rest -= take
+
+			l.lots[0].unit -= take
+			if l.lots[0].unit == 0 {
+				l.lots = l.lots[1:]
+			}
+
+			l.balanceUnit -= take
+			l.balanceAmount -= amount
+			l.appendIssueRow(day, note, take, price, amount)
+		}
+
+		return total, nil
+	}
+
+	var price float64
+	if l.Method == MovingAverage {
+		price = l.balanceAmount / float64(l.balanceUnit)
+	} else {
+		price = l.periodicPrice
+	}
+
+	amount := price * float64(unit)
+	l.balanceUnit -= unit
+	l.balanceAmount -= amount
+
+	// 端数で残高がずれないように残高0なら金額も0にする
+	if l.balanceUnit == 0 {
+		l.balanceAmount = 0
+	}
+
+	l.appendIssueRow(day, note, unit, price, amount)
+
+	return amount, nil
+}
+
+// appendIssueRow is 払出行を追加
+func (l *Ledger) appendIssueRow(day int, note string, unit int, price float64, amount float64) {
+	l.Rows = append(l.Rows, Row{
+		Day:           day,
+		Note:          note,
+		OutUnit:       unit,
+		OutPrice:      price,
+		OutAmount:     amount,
+		BalanceUnit:   l.balanceUnit,
+		BalanceAmount: l.balanceAmount,
+	})
+}
+
+// lastDay is 記帳の最終日を返す
+func lastDay(entries []Entry) int {
+	day := 1
+
+	for _, e := range entries {
+		if e.Day > day {
+			day = e.Day
+		}
+	}
+
+	return day
+}
@@ -0,0 +1,107 @@
+package materialcosting
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLedger(method PricingMethod) Ledger {
+	return Ledger{
+		Method:     method,
+		FirstUnit:  100,
+		FirstPrice: 500.0,
+		Entries: []Entry{
+			{Day: 5, Type: Receipt, Unit: 200, Price: 530.0},
+			{Day: 10, Type: Issue, Unit: 250, Direct: true},
+			{Day: 20, Type: Receipt, Unit: 150, Price: 550.0},
+			{Day: 25, Type: Issue, Unit: 80},
+		},
+		Stocktaking:    true,
+		ActualLastUnit: 110,
+	}
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		Method        PricingMethod
+		DirectCost    float64
+		IndirectCost  float64
+		ShrinkageCost float64
+		LastCost      float64
+	}{
+		{FIFO, 129500.0, 43000.0, 5500.0, 60500.0},
+		{MovingAverage, 130000.0, 43400.0, 5425.0, 59675.0},
+		{PeriodicAverage, 132500.0, 42400.0, 5300.0, 58300.0},
+	}
+
+	for _, testCase := range testCases {
+		ledger := newLedger(testCase.Method)
+
+		err := ledger.Run()
+		assert.NoError(t, err)
+		assert.InDelta(t, testCase.DirectCost, ledger.DirectCost, 1e-6)
+		assert.InDelta(t, testCase.IndirectCost, ledger.IndirectCost, 1e-6)
+		assert.InDelta(t, testCase.ShrinkageCost, ledger.ShrinkageCost, 1e-6)
+		assert.InDelta(t, testCase.LastCost, ledger.LastCost, 1e-6)
+		assert.Equal(t, 10, ledger.ShrinkageUnit)
+		assert.Equal(t, 110, ledger.LastUnit)
+	}
+}
+
+func TestRunFIFORows(t *testing.T) {
+	ledger := newLedger(FIFO)
+	ledger.Stocktaking = false
+
+	err := ledger.Run()
+	assert.NoError(t, err)
+
+	// 前月繰越, 受入, 払出2行, 受入, 払出2行
+	assert.Equal(t, 7, len(ledger.Rows))
+	assert.Equal(t, 100, ledger.Rows[2].OutUnit)
+	assert.Equal(t, 500.0, ledger.Rows[2].OutPrice)
+	assert.Equal(t, 150, ledger.Rows[3].OutUnit)
+	assert.Equal(t, 530.0, ledger.Rows[3].OutPrice)
+	assert.Equal(t, 550.0, ledger.Rows[6].BalancePrice())
+}
+
+func TestRunPredetermined(t *testing.T) {
+	ledger := newLedger(FIFO)
+	ledger.PredeterminedPrice = 520.0
+
+	err := ledger.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 130000.0, ledger.DirectCost)
+	assert.Equal(t, 41600.0, ledger.IndirectCost)
+	assert.Equal(t, 172500.0, ledger.ActualCost)
+	assert.Equal(t, -900.0, ledger.PriceVariance)
+	assert.False(t, ledger.IsFavorable())
+}
+
+func TestRunShortage(t *testing.T) {
+	ledger := Ledger{
+		FirstUnit:  10,
+		FirstPrice: 100.0,
+		Entries: []Entry{
+			{Day: 3, Type: Issue, Unit: 20, Direct: true},
+		},
+	}
+
+	err := ledger.Run()
+	assert.ErrorIs(t, err, ErrShortage)
+}
+
+func TestAddTo(t *testing.T) {
+	ledger := newLedger(FIFO)
+	assert.NoError(t, ledger.Run())
+
+	var material totalcosting.Cost
+	ledger.AddTo(&material)
+	assert.Equal(t, 129500.0, material.InputCost)
+
+	// 他の原価を加えた後でも上書きしない
+	material.InputCost = 10000
+	ledger.AddTo(&material)
+	assert.Equal(t, 139500.0, material.InputCost)
+}
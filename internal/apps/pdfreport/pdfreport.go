@@ -0,0 +1,415 @@
+package pdfreport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// ErrNoFont is フォントが指定されていない
+// 日本語を表示するためにTrueTypeフォントの埋め込みが必要
+var ErrNoFont = errors.New("pdfreport: font file is required")
+
+// Header is 各ページの見出し
+type Header struct {
+	Company string // 会社名
+	Plant   string // 工場名
+	Period  string // 会計期間
+}
+
+// Process is 報告する工程
+type Process struct {
+	Name string
+	Box  totalcosting.Box // Run済みであること
+}
+
+// Options is PDFの設定
+type Options struct {
+	Header       Header
+	FontPath     string // 日本語を含むTrueTypeフォント(必須)
+	BoldFontPath string // 見出しのフォント, 空ならFontPathと同じ
+	NoTrace      bool   // 計算過程を載せない
+}
+
+// フォントとページのレイアウト
+const (
+	fontFamily = "report"
+	pageWidth  = 180.0 // 余白を除いたA4の幅(mm)
+	lineHeight = 6.0
+	boxHeight  = 70.0 // 数量に比例させるBox図の高さ(mm)
+	itemLine   = 3.6  // Box図の中の1行の高さ(mm)
+)
+
+// Write is 工程ごとの報告書をPDFで書き出す
+func Write(w io.Writer, processes []Process, opts Options) error {
+	if opts.FontPath == "" {
+		return ErrNoFont
+	}
+
+	font, err := ioutil.ReadFile(opts.FontPath)
+	if err != nil {
+		return err
+	}
+	bold := font
+	if opts.BoldFontPath != "" {
+		if bold, err = ioutil.ReadFile(opts.BoldFontPath); err != nil {
+			return err
+		}
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", font)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", bold)
+	pdf.SetTitle("総合原価計算報告書", true)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+
+	r := report{pdf: pdf, header: opts.Header}
+	pdf.SetHeaderFunc(r.writeHeader)
+	pdf.SetFooterFunc(r.writeFooter)
+
+	for _, p := range processes {
+		r.writeProcess(p, !opts.NoTrace)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+
+	return pdf.Output(w)
+}
+
+// WriteFile is 工程ごとの報告書をPDFファイルに書き出す
+func WriteFile(name string, processes []Process, opts Options) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, processes, opts); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// report is 書き出し中の報告書
+type report struct {
+	pdf    *gofpdf.Fpdf
+	header Header
+}
+
+// writeHeader is 会社名, 工場名, 会計期間をページの上に書く
+func (r report) writeHeader() {
+	r.pdf.SetFont(fontFamily, "", 8)
+
+	var left []string
+	for _, s := range []string{r.header.Company, r.header.Plant} {
+		if s != "" {
+			left = append(left, s)
+		}
+	}
+
+	r.pdf.CellFormat(pageWidth/2, 5, strings.Join(left, " "), "", 0, "L", false, 0, "")
+	r.pdf.CellFormat(pageWidth/2, 5, r.header.Period, "", 1, "R", false, 0, "")
+	r.pdf.Ln(3)
+}
+
+// writeFooter is ページ番号を書く
+func (r report) writeFooter() {
+	r.pdf.SetY(-12)
+	r.pdf.SetFont(fontFamily, "", 8)
+	r.pdf.CellFormat(0, 5, fmt.Sprintf("%d / {nb}", r.pdf.PageNo()), "", 0, "C", false, 0, "")
+}
+
+// writeProcess is 工程1つの報告を新しいページから書く
+func (r report) writeProcess(p Process, trace bool) {
+	r.pdf.AddPage()
+
+	title := "総合原価計算報告書"
+	if p.Name != "" {
+		title += " " + p.Name
+	}
+	r.pdf.SetFont(fontFamily, "B", 14)
+	r.pdf.CellFormat(pageWidth, 10, title, "", 1, "C", false, 0, "")
+	r.pdf.Ln(2)
+
+	r.writeSummary(p.Box)
+	r.writeDiagrams(p.Box)
+	r.writeUnits(p.Box)
+	r.writeAllocation(p.Box)
+	r.writeLoss(p.Box)
+	if trace {
+		r.writeTrace(p.Box)
+	}
+}
+
+// heading is 節の見出しを書く
+func (r report) heading(s string) {
+	r.pdf.Ln(3)
+	r.pdf.SetFont(fontFamily, "B", 11)
+	r.pdf.CellFormat(pageWidth, 8, s, "B", 1, "L", false, 0, "")
+	r.pdf.Ln(1)
+	r.pdf.SetFont(fontFamily, "", 9)
+}
+
+// row is 表の1行を書く
+// 先頭の列は左寄せ, それ以外は右寄せ
+func (r report) row(widths []float64, cells []string, head bool) {
+	if head {
+		r.pdf.SetFont(fontFamily, "B", 9)
+		r.pdf.SetFillColor(235, 235, 235)
+	}
+
+	for i, c := range cells {
+		align := "R"
+		if i == 0 || head {
+			align = "L"
+		}
+		r.pdf.CellFormat(widths[i], lineHeight, c, "1", 0, align, head, 0, "")
+	}
+	r.pdf.Ln(-1)
+
+	if head {
+		r.pdf.SetFont(fontFamily, "", 9)
+	}
+}
+
+// writeSummary is 完成品原価, 完成品単位原価, 月末仕掛品原価を書く
+func (r report) writeSummary(box totalcosting.Box) {
+	r.heading("集計")
+
+	widths := []float64{60, 40}
+	r.row(widths, []string{"完成品原価", costreport.FormatYen(box.ProductTotalCost)}, false)
+	r.row(widths, []string{"完成品単位原価", costreport.FormatPrice(box.ProductAvgCost)}, false)
+	r.row(widths, []string{"月末仕掛品原価", costreport.FormatYen(box.EOTMTotalCost)}, false)
+}
+
+// writeDiagrams is 原価要素ごとのBox図を描く
+func (r report) writeDiagrams(box totalcosting.Box) {
+	r.heading("Box図")
+
+	for _, d := range boxdiagram.New(box) {
+		left := heights(d.Left, d.Units())
+		right := heights(d.Right, d.Units())
+		height := fill(left, right)
+
+		// Box図の途中で改ページしない
+		_, pageHeight := r.pdf.GetPageSize()
+		_, _, _, bottom := r.pdf.GetMargins()
+		if r.pdf.GetY()+height+12 > pageHeight-bottom-15 {
+			r.pdf.AddPage()
+		}
+
+		r.pdf.SetFont(fontFamily, "B", 9)
+		r.pdf.CellFormat(pageWidth, lineHeight, d.Name+"  "+d.Method+"  単価 "+costreport.FormatPrice(d.Price), "", 1, "L", false, 0, "")
+
+		x, top := r.pdf.GetX(), r.pdf.GetY()
+		r.writeSide(d.Left, left, x, top)
+		r.writeSide(d.Right, right, x+pageWidth/2, top)
+
+		r.pdf.SetLineWidth(0.5)
+		r.pdf.Rect(x, top, pageWidth, height, "D")
+		r.pdf.SetLineWidth(0.2)
+
+		r.pdf.SetXY(x, top+height+4)
+	}
+}
+
+// writeSide is Box図の片側を描く
+// 正常仕損費を負担する要素は塗り, 正常仕損は破線で囲む
+func (r report) writeSide(items []boxdiagram.Item, heights []float64, x float64, y float64) {
+	for i, it := range items {
+		switch {
+		case it.IsNormalLoss():
+			r.pdf.SetFillColor(253, 226, 226)
+			r.pdf.SetDashPattern([]float64{1, 0.6}, 0)
+		case it.IsBearer():
+			r.pdf.SetFillColor(255, 242, 204)
+		default:
+			r.pdf.SetFillColor(255, 255, 255)
+		}
+		r.pdf.Rect(x, y, pageWidth/2, heights[i], "FD")
+		r.pdf.SetDashPattern([]float64{}, 0)
+
+		for k, line := range it.Lines() {
+			style := ""
+			if k == 0 {
+				style = "B"
+			}
+			r.pdf.SetFont(fontFamily, style, 7)
+			r.pdf.Text(x+2, y+itemLine*float64(k+1)+0.5, line)
+		}
+
+		y += heights[i]
+	}
+}
+
+// writeUnits is 完成品換算量の表を書く
+func (r report) writeUnits(box totalcosting.Box) {
+	r.heading("完成品換算量")
+
+	names := costNames(box)
+	widths := columnWidths(len(names) + 2)
+
+	r.row(widths, append([]string{"区分", "数量"}, names...), true)
+	for j, m := range box.Master {
+		cells := []string{m.Type.Label(), costreport.FormatYen(float64(m.Unit))}
+		for _, c := range box.Costs {
+			unit := ""
+			if j < len(c.Elements) {
+				unit = costreport.FormatYen(float64(c.Elements[j].Unit))
+			}
+			cells = append(cells, unit)
+		}
+		r.row(widths, cells, false)
+	}
+}
+
+// writeAllocation is 原価の配分の表を書く
+func (r report) writeAllocation(box totalcosting.Box) {
+	r.heading("原価の配分")
+
+	widths := []float64{40, 30, 25, 25, 30, 30}
+	r.row(widths, []string{"原価要素", "区分", "換算量", "負担量", "単価", "原価"}, true)
+
+	for _, d := range boxdiagram.New(box) {
+		for _, it := range append(append([]boxdiagram.Item{}, d.Left...), d.Right...) {
+			r.row(widths, []string{
+				d.Name,
+				it.Type.Label(),
+				costreport.FormatYen(float64(it.Conversion)),
+				costreport.FormatYen(float64(it.NDBurden)),
+				costreport.FormatPrice(it.Price),
+				costreport.FormatYen(it.Cost),
+			}, false)
+		}
+	}
+}
+
+// writeLoss is 仕損と減損の処理を書く
+func (r report) writeLoss(box totalcosting.Box) {
+	r.heading("仕損・減損の処理")
+
+	names := costNames(box)
+	found := false
+
+	for i, c := range box.Costs {
+		for _, e := range c.Elements {
+			var text string
+			switch e.Type {
+			case totalcosting.NormalDefect, totalcosting.NormalImpairment:
+				text = fmt.Sprintf("%s: %s費 %s円を%sで処理", names[i], e.Type.Label(), costreport.FormatYen(e.Cost()), lossBranch(box.Trace, i, e.Type))
+			case totalcosting.AbnormalDefect, totalcosting.AbnormalImpairment:
+				text = fmt.Sprintf("%s: %s費 %s円は非原価項目", names[i], e.Type.Label(), costreport.FormatYen(e.Cost()))
+			default:
+				continue
+			}
+
+			found = true
+			r.pdf.MultiCell(pageWidth, lineHeight, text, "", "L", false)
+		}
+	}
+
+	if !found {
+		r.pdf.MultiCell(pageWidth, lineHeight, "仕損・減損はない", "", "L", false)
+	}
+}
+
+// writeTrace is 計算過程を書く
+func (r report) writeTrace(box totalcosting.Box) {
+	r.heading("計算過程")
+
+	r.pdf.SetFont(fontFamily, "", 8)
+	for i, s := range box.Trace {
+		r.pdf.MultiCell(pageWidth, 4.5, fmt.Sprintf("%d. %s", i+1, s), "", "L", false)
+	}
+}
+
+// lossBranch is 計算過程から正常仕損費の処理方法を返す
+func lossBranch(trace totalcosting.Trace, i int, t totalcosting.ElementType) string {
+	if s, ok := trace.Find(fmt.Sprintf("costs[%d].cost.%s", i, t)); ok {
+		return s.Branch
+	}
+
+	return "-"
+}
+
+// costNames is 原価要素の名前を返す
+func costNames(box totalcosting.Box) []string {
+	var names []string
+
+	for _, d := range boxdiagram.New(box) {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
+
+// columnWidths is n列の表の列幅を返す
+func columnWidths(n int) []float64 {
+	widths := make([]float64, n)
+
+	for i := range widths {
+		widths[i] = pageWidth / float64(n)
+	}
+
+	return widths
+}
+
+// heights is 数量に比例した要素の高さを返す
+// 文字が収まるように最低の高さを確保する
+func heights(items []boxdiagram.Item, total int) []float64 {
+	result := make([]float64, len(items))
+
+	for i, it := range items {
+		minimum := itemLine*float64(len(it.Lines())) + 2
+
+		h := minimum
+		if total > 0 {
+			h = boxHeight * float64(it.Unit) / float64(total)
+		}
+		if h < minimum {
+			h = minimum
+		}
+
+		result[i] = h
+	}
+
+	return result
+}
+
+// fill is 低い方の側の最後の要素を伸ばして左右の高さを揃え, 高さを返す
+func fill(left []float64, right []float64) float64 {
+	l, r := 0.0, 0.0
+	for _, h := range left {
+		l += h
+	}
+	for _, h := range right {
+		r += h
+	}
+
+	switch {
+	case l < r && len(left) > 0:
+		left[len(left)-1] += r - l
+		return r
+	case r < l && len(right) > 0:
+		right[len(right)-1] += l - r
+		return l
+	}
+
+	if l > r {
+		return l
+	}
+
+	return r
+}
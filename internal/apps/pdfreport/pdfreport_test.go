@@ -0,0 +1,81 @@
+package pdfreport
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFont is テストで埋め込むフォント
+// 日本語の字形はないが埋め込みと配置は確認できる
+const testFont = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+
+// newDefectBox is 正常仕損と異常仕損のある問題
+func newDefectBox() totalcosting.Box {
+	return totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.First, Unit: 400, Progress: 0.5},
+			{Type: totalcosting.Input, Unit: 2000},
+			{Type: totalcosting.Output, Unit: 1700},
+			{Type: totalcosting.NormalDefect, Unit: 100, Progress: 0.4},
+			{Type: totalcosting.AbnormalDefect, Unit: 100, Progress: 0.8},
+			{Type: totalcosting.Last, Unit: 500, Progress: 0.6},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "直接材料費", CMethod: totalcosting.AVG, DMethod: totalcosting.Neglecting, FirstCost: 80000, InputCost: 380000},
+			{Name: "加工費", InputOnAvg: true, CMethod: totalcosting.FIFO, DMethod: totalcosting.NonNeglecting, FirstCost: 60000, InputCost: 954000},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	if _, err := os.Stat(testFont); err != nil {
+		t.Skip("font is not installed")
+	}
+
+	box := newDefectBox()
+	box.Run()
+
+	opts := Options{
+		Header:   Header{Company: "Costing Inc.", Plant: "Plant 1", Period: "2020-04"},
+		FontPath: testFont,
+	}
+	processes := []Process{{Name: "第1工程", Box: box}, {Name: "第2工程", Box: box}}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, processes, opts))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, buf.String(), "/FontFile2")
+
+	// 計算過程を載せなければ小さくなる
+	var short bytes.Buffer
+	opts.NoTrace = true
+	assert.NoError(t, Write(&short, processes, opts))
+	assert.True(t, short.Len() < buf.Len())
+}
+
+func TestWriteError(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.Equal(t, ErrNoFont, Write(&buf, nil, Options{}))
+	assert.Error(t, Write(&buf, nil, Options{FontPath: "testdata/missing.ttf"}))
+}
+
+func TestHeights(t *testing.T) {
+	box := newDefectBox()
+	box.Run()
+	d := boxdiagram.New(box)[0]
+
+	// 完成品は数量に比例し, 正常仕損は最低の高さ
+	right := heights(d.Right, d.Units())
+	assert.Equal(t, boxHeight*1700/2400, right[0])
+	assert.Equal(t, itemLine*5+2, right[1])
+
+	left := heights(d.Left, d.Units())
+	height := fill(left, right)
+	assert.InDelta(t, height, left[0]+left[1], 1e-9)
+}
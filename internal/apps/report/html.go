@@ -0,0 +1,74 @@
+package report
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Doc.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 0.3em 0.6em; }
+th { background: #eee; text-align: left; }
+td { text-align: right; }
+td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Doc.Title}}</h1>
+{{- range .Doc.Tables}}
+<h2>{{.Name}}</h2>
+<p>{{.Method}}<br>{{.Price}}</p>
+<table>
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+<h2>{{.Doc.TotalsTitle}}</h2>
+<table>
+<thead><tr>{{range .Doc.TotalHeader}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Doc.Totals}}
+<tr><td>{{.Label}}</td><td>{{.Amount}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// HTML is Run済みのBoxを原価要素ごとの表と集計の単独で開けるHTMLにする
+func HTML(box totalcosting.Box, lang Lang) (string, error) {
+	var buf bytes.Buffer
+
+	if err := WriteHTML(&buf, box, lang); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// WriteHTML is Run済みのBoxをHTMLで書き出す
+func WriteHTML(w io.Writer, box totalcosting.Box, lang Lang) error {
+	code := "ja"
+	if lang == English {
+		code = "en"
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Lang string
+		Doc  document
+	}{code, newDocument(box, lang)})
+}
@@ -0,0 +1,25 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	box := newDefectBox()
+	box.Costs[0].Name = "<材料>"
+	box.Run()
+
+	html, err := HTML(box, Japanese)
+	assert.NoError(t, err)
+	assert.Contains(t, html, `<html lang="ja">`)
+	assert.Contains(t, html, "<h2>&lt;材料&gt;</h2>")
+	assert.Contains(t, html, "<tr><td>月末仕掛品</td><td>500</td><td>500</td><td>500</td><td>200</td><td>100,000</td></tr>")
+	assert.Contains(t, html, "<tr><td>完成品原価</td><td>1,229,143</td></tr>")
+
+	html, err = HTML(box, English)
+	assert.NoError(t, err)
+	assert.Contains(t, html, `<html lang="en">`)
+	assert.Contains(t, html, "<th>Equivalent units</th>")
+}
@@ -0,0 +1,255 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Box図の大きさ(cm)
+const (
+	latexBoxWidth  = 4.0
+	latexBoxHeight = 8.0
+	latexMinHeight = 1.6 // 1つの要素の最小の高さ
+)
+
+// LaTeX is Run済みのBoxを解答・解説のLaTeX文書にする
+// 原価要素ごとにTikZのBox図と単価, 配分, 正常仕損費の計算式を載せ, 最後に解答の表を置く
+// 日本語を含むのでLuaLaTeX(ltjsarticle)で組版する
+func LaTeX(box totalcosting.Box) string {
+	doc := newDocument(box, Japanese)
+
+	var sb strings.Builder
+	sb.WriteString(`\documentclass{ltjsarticle}
+\usepackage{amsmath}
+\usepackage{tikz}
+\begin{document}
+`)
+	sb.WriteString(`\section*{` + escapeLaTeX(doc.Title) + "}\n")
+
+	for i, d := range boxdiagram.New(box) {
+		sb.WriteString("\n" + `\subsection*{` + escapeLaTeX(doc.Tables[i].Name) + "}\n")
+		sb.WriteString(escapeLaTeX(doc.Tables[i].Method) + "\n\n")
+		writeTikZ(&sb, d)
+		writeEquations(&sb, box.Trace, i)
+	}
+
+	sb.WriteString("\n" + `\subsection*{解答}` + "\n")
+	sb.WriteString(`\begin{center}
+\begin{tabular}{lr}
+\hline
+`)
+	sb.WriteString(escapeLaTeX(doc.TotalHeader[0]) + " & " + escapeLaTeX(doc.TotalHeader[1]) + ` \\` + "\n\\hline\n")
+	for _, l := range doc.Totals {
+		sb.WriteString(escapeLaTeX(l.Label) + " & " + escapeLaTeX(l.Amount) + `円 \\` + "\n")
+	}
+	sb.WriteString(`\hline
+\end{tabular}
+\end{center}
+
+\end{document}
+`)
+
+	return sb.String()
+}
+
+// WriteLaTeX is Run済みのBoxをLaTeXで書き出す
+func WriteLaTeX(w io.Writer, box totalcosting.Box) error {
+	_, err := io.WriteString(w, LaTeX(box))
+
+	return err
+}
+
+// writeTikZ is 原価要素1つのBox図をTikZで描く
+// 高さは数量に比例させ, 低い方の側の最後の要素を伸ばして左右を揃える
+func writeTikZ(sb *strings.Builder, d boxdiagram.Diagram) {
+	total := d.Units()
+	left := latexHeights(d.Left, total)
+	right := latexHeights(d.Right, total)
+
+	l, r := latexSum(left), latexSum(right)
+	if l < r && len(left) > 0 {
+		left[len(left)-1] += r - l
+	}
+	if r < l && len(right) > 0 {
+		right[len(right)-1] += l - r
+	}
+
+	sb.WriteString(`\begin{center}
+\begin{tikzpicture}[font=\small]
+`)
+	writeTikZColumn(sb, d.Left, left, 0)
+	writeTikZColumn(sb, d.Right, right, latexBoxWidth)
+	sb.WriteString(`\end{tikzpicture}
+\end{center}
+`)
+}
+
+// writeTikZColumn is 片側の要素を上から順に描く
+func writeTikZColumn(sb *strings.Builder, items []boxdiagram.Item, heights []float64, x float64) {
+	y := 0.0
+
+	for k, it := range items {
+		style := "draw"
+		switch {
+		case it.IsNormalLoss():
+			style = "draw, fill=red!10"
+		case it.IsBearer():
+			style = "draw, fill=blue!10"
+		}
+
+		lines := make([]string, len(it.Lines()))
+		for n, line := range it.Lines() {
+			lines[n] = escapeLaTeX(line)
+		}
+
+		fmt.Fprintf(sb, "\\draw[%s] (%s,%s) rectangle (%s,%s);\n",
+			style, latexNumber(x), latexNumber(-y), latexNumber(x+latexBoxWidth), latexNumber(-y-heights[k]))
+		fmt.Fprintf(sb, "\\node[align=left] at (%s,%s) {%s};\n",
+			latexNumber(x+latexBoxWidth/2), latexNumber(-y-heights[k]/2), strings.Join(lines, `\\`))
+
+		y += heights[k]
+	}
+}
+
+// latexHeights is 要素ごとの高さを返す
+func latexHeights(items []boxdiagram.Item, total int) []float64 {
+	result := make([]float64, len(items))
+
+	for i, it := range items {
+		h := latexMinHeight
+		if total > 0 {
+			h = latexBoxHeight * float64(it.Unit) / float64(total)
+		}
+		if h < latexMinHeight {
+			h = latexMinHeight
+		}
+
+		result[i] = h
+	}
+
+	return result
+}
+
+// latexSum is 高さの合計を返す
+func latexSum(heights []float64) float64 {
+	total := 0.0
+
+	for _, h := range heights {
+		total += h
+	}
+
+	return total
+}
+
+// latexNumber is 座標を小数第2位までで表す
+func latexNumber(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", v), "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+
+	return s
+}
+
+// latexStages is 計算式を載せる段階と見出し
+var latexStages = []struct {
+	Stage string
+	Title string
+}{
+	{totalcosting.StagePrice, "単価"},
+	{totalcosting.StageAllocation, "原価の配分"},
+	{totalcosting.StageNormalLoss, "正常仕損費の負担"},
+}
+
+// writeEquations is i番目の原価要素の計算過程を段階ごとにalign*で書く
+func writeEquations(sb *strings.Builder, trace totalcosting.Trace, i int) {
+	for _, stage := range latexStages {
+		var steps []totalcosting.Step
+		for _, s := range trace {
+			if s.Cost == i && s.Stage == stage.Stage {
+				steps = append(steps, s)
+			}
+		}
+		if len(steps) == 0 {
+			continue
+		}
+
+		title := stage.Title
+		if steps[0].Branch != "" {
+			title += "(" + steps[0].Branch + ")"
+		}
+		sb.WriteString(`\paragraph{` + escapeLaTeX(title) + "}\n")
+		sb.WriteString(`\begin{align*}` + "\n")
+
+		for n, s := range steps {
+			if n > 0 {
+				sb.WriteString(` \\` + "\n")
+			}
+			sb.WriteString(latexKeyLabel(s.Key) + ` &= \text{` + escapeLaTeX(s.Formula) + `} \\` + "\n")
+			sb.WriteString(` &= `)
+			if s.Expression != "" {
+				sb.WriteString(latexExpression(s.Expression) + ` = `)
+			}
+			sb.WriteString(latexAmount(s.Value))
+		}
+
+		sb.WriteString("\n" + `\end{align*}` + "\n")
+	}
+}
+
+// latexKeyLabel is 計算過程のキーから式の左辺を返す
+func latexKeyLabel(key string) string {
+	parts := strings.Split(key, ".")
+
+	switch {
+	case len(parts) == 2 && parts[1] == "price":
+		return `\text{単価}`
+	case len(parts) == 3 && parts[1] == "cost":
+		return `\text{` + escapeLaTeX(typeLabel(parts[2])) + `の原価}`
+	case len(parts) == 3 && parts[1] == "burden":
+		return `\text{` + escapeLaTeX(typeLabel(parts[2])) + `の負担額}`
+	}
+
+	return `\text{` + escapeLaTeX(key) + `}`
+}
+
+// typeLabel is ElementTypeの名前から日本語の名前を返す
+func typeLabel(name string) string {
+	var t totalcosting.ElementType
+	if err := t.UnmarshalText([]byte(name)); err == nil {
+		return t.Label()
+	}
+
+	return name
+}
+
+// latexExpressionReplacer is 計算過程の式の記号をLaTeXにする
+var latexExpressionReplacer = strings.NewReplacer("×", `\times`, "÷", `\div`)
+
+// latexExpression is 数値を当てはめた式をLaTeXの数式にする
+func latexExpression(s string) string {
+	return latexExpressionReplacer.Replace(s)
+}
+
+// latexAmount is 計算結果を桁区切りして数式で表す
+func latexAmount(v float64) string {
+	return strings.ReplaceAll(costreport.FormatPrice(v), ",", "{,}")
+}
+
+// latexEscaper is LaTeXの特殊文字をエスケープする
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+	"{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+// escapeLaTeX is LaTeXの特殊文字をエスケープする
+func escapeLaTeX(s string) string {
+	return latexEscaper.Replace(s)
+}
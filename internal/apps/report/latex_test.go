@@ -0,0 +1,53 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaTeX(t *testing.T) {
+	box := newDefectBox()
+	box.Costs = box.Costs[:1]
+	box.Costs[0].Name = "材料_A"
+	box.Run()
+
+	latex := LaTeX(box)
+	assert.True(t, strings.HasPrefix(latex, `\documentclass{ltjsarticle}`))
+	assert.True(t, strings.HasSuffix(latex, "\\end{document}\n"))
+	assert.Contains(t, latex, `\subsection*{材料\_A}`)
+	assert.Contains(t, latex, `\draw[draw] (0,0) rectangle (4,-1.6);`)
+	assert.Contains(t, latex, `\draw[draw, fill=red!10] (4,-6) rectangle (8,-7.6);`)
+	assert.Contains(t, latex, `\paragraph{単価(平均法)}`)
+	// 度外視法・両者負担なので正常仕損を除いた換算量で単価を計算する
+	assert.Contains(t, latex, ` &= (80000 + 380000) \div (400 + 1900) = 200`)
+	assert.Contains(t, latex, `\paragraph{正常仕損費の負担(度外視法・両者負担)}`)
+	assert.Contains(t, latex, `\text{正常仕損の原価} &= \text{正常仕損を除いた換算量で単価を計算したので配分しない} \\`+"\n &= 0\n")
+	assert.NotContains(t, latex, `の負担額}`)
+	assert.Contains(t, latex, `完成品原価 & 360,000円 \\`)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteLaTeX(&buf, box))
+	assert.Equal(t, latex, buf.String())
+}
+
+func TestEscapeLaTeX(t *testing.T) {
+	testCases := []struct {
+		S      string
+		Result string
+	}{
+		{"原価要素1", "原価要素1"},
+		{"50% & $", `50\% \& \$`},
+		{`a\b`, `a\textbackslash{}b`},
+		{"{x}_1", `\{x\}\_1`},
+	}
+
+	for _, testCase := range testCases {
+		result := escapeLaTeX(testCase.S)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+		}
+	}
+}
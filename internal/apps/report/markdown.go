@@ -0,0 +1,74 @@
+package report
+
+import (
+	"io"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Markdown is Run済みのBoxを原価要素ごとの表と集計のMarkdownにする
+func Markdown(box totalcosting.Box, lang Lang) string {
+	doc := newDocument(box, lang)
+
+	var sb strings.Builder
+	sb.WriteString("# " + escapeMarkdown(doc.Title) + "\n")
+
+	for _, t := range doc.Tables {
+		sb.WriteString("\n## " + escapeMarkdown(t.Name) + "\n\n")
+		sb.WriteString(escapeMarkdown(t.Method) + "  \n" + escapeMarkdown(t.Price) + "\n\n")
+		writeMarkdownTable(&sb, t.Header, t.Rows)
+	}
+
+	sb.WriteString("\n## " + escapeMarkdown(doc.TotalsTitle) + "\n\n")
+	rows := make([][]string, len(doc.Totals))
+	for i, l := range doc.Totals {
+		rows[i] = []string{l.Label, l.Amount}
+	}
+	writeMarkdownTable(&sb, doc.TotalHeader, rows)
+
+	return sb.String()
+}
+
+// WriteMarkdown is Run済みのBoxをMarkdownで書き出す
+func WriteMarkdown(w io.Writer, box totalcosting.Box, lang Lang) error {
+	_, err := io.WriteString(w, Markdown(box, lang))
+
+	return err
+}
+
+// writeMarkdownTable is 表を書く
+// 先頭の列は左寄せ, それ以外は右寄せ
+func writeMarkdownTable(sb *strings.Builder, header []string, rows [][]string) {
+	align := make([]string, len(header))
+	for i := range header {
+		align[i] = "---:"
+		if i == 0 {
+			align[i] = "---"
+		}
+	}
+
+	writeMarkdownRow(sb, header)
+	sb.WriteString("| " + strings.Join(align, " | ") + " |\n")
+	for _, row := range rows {
+		writeMarkdownRow(sb, row)
+	}
+}
+
+// writeMarkdownRow is 表の1行を書く
+func writeMarkdownRow(sb *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = escapeMarkdown(c)
+	}
+
+	sb.WriteString("| " + strings.Join(escaped, " | ") + " |\n")
+}
+
+// markdownEscaper is Markdownの記号をエスケープする
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "#", `\#`, "<", "&lt;", ">", "&gt;")
+
+// escapeMarkdown is Markdownの記号をエスケープする
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	box := newDefectBox()
+	box.Costs = box.Costs[:1]
+	box.Run()
+
+	expected := `# 総合原価計算
+
+## 直接材料費
+
+定点投入(投入点0) / 平均法 / 度外視法  
+単価 200
+
+| 区分 | 数量 | 換算量 | 負担量 | 単価 | 原価 |
+| --- | ---: | ---: | ---: | ---: | ---: |
+| 月初仕掛品 | 400 | 400 | 0 | 200 | 80,000 |
+| 当月投入 | 2,000 | 2,000 | 0 | 190 | 380,000 |
+| 完成品 | 1,800 | 1,800 | 1,800 | 200 | 360,000 |
+| 正常仕損 | 100 | 100 | 0 | 191.67 | 19,167 |
+| 月末仕掛品 | 500 | 500 | 500 | 200 | 100,000 |
+
+## 集計
+
+| 項目 | 金額 |
+| --- | ---: |
+| 完成品原価 | 360,000 |
+| 完成品単位原価 | 200 |
+| 月末仕掛品原価 | 100,000 |
+`
+	assert.Equal(t, expected, Markdown(box, Japanese))
+
+	var buf bytes.Buffer
+	box.Costs[0].Name = "A|B"
+	assert.NoError(t, WriteMarkdown(&buf, box, English))
+	assert.Contains(t, buf.String(), "## A\\|B")
+	assert.Contains(t, buf.String(), "| Completed | 1,800 | 1,800 | 1,800 | 200 | 360,000 |")
+	assert.Contains(t, buf.String(), "| Cost of goods completed | 360,000 |")
+}
@@ -0,0 +1,181 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// Lang is 報告書の言語
+type Lang int
+
+// 報告書の言語(日本語 or 英語)
+const (
+	Japanese Lang = iota
+	English
+)
+
+// ParseLang is 言語コード(ja, en)からLangを返す
+func ParseLang(s string) (Lang, error) {
+	switch s {
+	case "ja", "":
+		return Japanese, nil
+	case "en":
+		return English, nil
+	}
+
+	return Japanese, fmt.Errorf("report: unknown language %q", s)
+}
+
+// labels is 言語ごとの見出し
+var labels = map[Lang]map[string]string{
+	Japanese: {
+		"title":   "総合原価計算",
+		"cost":    "原価要素%d",
+		"price":   "単価",
+		"type":    "区分",
+		"unit":    "数量",
+		"conv":    "換算量",
+		"burden":  "負担量",
+		"total":   "原価",
+		"totals":  "集計",
+		"product": "完成品原価",
+		"avg":     "完成品単位原価",
+		"eotm":    "月末仕掛品原価",
+		"item":    "項目",
+		"amount":  "金額",
+		"uniform": "平均的投入",
+		"point":   "定点投入(投入点%s)",
+	},
+	English: {
+		"title":   "Process Costing",
+		"cost":    "Cost %d",
+		"price":   "Unit price",
+		"type":    "Type",
+		"unit":    "Units",
+		"conv":    "Equivalent units",
+		"burden":  "NDBurden",
+		"total":   "Total",
+		"totals":  "Totals",
+		"product": "Cost of goods completed",
+		"avg":     "Unit cost of goods completed",
+		"eotm":    "Ending work in process",
+		"item":    "Item",
+		"amount":  "Amount",
+		"uniform": "Uniform input",
+		"point":   "Input at %s",
+	},
+}
+
+// elementLabels is 英語の要素の名前
+var elementLabels = map[totalcosting.ElementType]string{
+	totalcosting.First:              "Beginning work in process",
+	totalcosting.Input:              "Started",
+	totalcosting.Output:             "Completed",
+	totalcosting.Last:               "Ending work in process",
+	totalcosting.NormalDefect:       "Normal defect",
+	totalcosting.AbnormalDefect:     "Abnormal defect",
+	totalcosting.NormalImpairment:   "Normal impairment",
+	totalcosting.AbnormalImpairment: "Abnormal impairment",
+}
+
+// label is 見出しを返す
+func (l Lang) label(key string) string {
+	return labels[l][key]
+}
+
+// element is 要素の種別の名前を返す
+func (l Lang) element(t totalcosting.ElementType) string {
+	if l == English {
+		if s, ok := elementLabels[t]; ok {
+			return s
+		}
+	}
+
+	return t.Label()
+}
+
+// table is 原価要素1つの表
+type table struct {
+	Name   string
+	Method string
+	Price  string
+	Header []string
+	Rows   [][]string
+}
+
+// line is 集計の1行
+type line struct {
+	Label  string
+	Amount string
+}
+
+// document is 報告書の内容
+type document struct {
+	Title       string
+	Tables      []table
+	TotalsTitle string
+	TotalHeader []string
+	Totals      []line
+}
+
+// newDocument is Run済みのBoxから報告書の内容を作る
+func newDocument(box totalcosting.Box, lang Lang) document {
+	doc := document{
+		Title:       lang.label("title"),
+		TotalsTitle: lang.label("totals"),
+		TotalHeader: []string{lang.label("item"), lang.label("amount")},
+		Totals: []line{
+			{lang.label("product"), costreport.FormatYen(box.ProductTotalCost)},
+			{lang.label("avg"), costreport.FormatPrice(box.ProductAvgCost)},
+			{lang.label("eotm"), costreport.FormatYen(box.EOTMTotalCost)},
+		},
+	}
+
+	for i, d := range boxdiagram.New(box) {
+		c := box.Costs[i]
+
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf(lang.label("cost"), i+1)
+		}
+
+		method := lang.label("uniform")
+		if !c.InputOnAvg {
+			method = fmt.Sprintf(lang.label("point"), strconv.FormatFloat(c.InputTiming, 'f', -1, 64))
+		}
+		if lang == English {
+			method += " / " + c.CMethod.String() + " / " + c.DMethod.String()
+		} else {
+			method += " / " + c.CMethod.Label() + " / " + c.DMethod.Label()
+		}
+
+		t := table{
+			Name:   name,
+			Method: method,
+			Price:  lang.label("price") + " " + costreport.FormatPrice(d.Price),
+			Header: []string{
+				lang.label("type"), lang.label("unit"), lang.label("conv"),
+				lang.label("burden"), lang.label("price"), lang.label("total"),
+			},
+		}
+
+		for _, it := range append(append([]boxdiagram.Item{}, d.Left...), d.Right...) {
+			t.Rows = append(t.Rows, []string{
+				lang.element(it.Type),
+				costreport.FormatYen(float64(it.Unit)),
+				costreport.FormatYen(float64(it.Conversion)),
+				costreport.FormatYen(float64(it.NDBurden)),
+				costreport.FormatPrice(it.Price),
+				costreport.FormatYen(it.Cost),
+			})
+		}
+
+		doc.Tables = append(doc.Tables, t)
+	}
+
+	return doc
+}
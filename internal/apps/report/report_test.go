@@ -0,0 +1,53 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDefectBox is 正常仕損のある問題
+func newDefectBox() totalcosting.Box {
+	return totalcostingtest.DefectBox(totalcosting.AVG, totalcosting.Neglecting)
+}
+
+func TestParseLang(t *testing.T) {
+	testCases := []struct {
+		S      string
+		Result Lang
+	}{
+		{"", Japanese},
+		{"ja", Japanese},
+		{"en", English},
+	}
+
+	for _, testCase := range testCases {
+		result, err := ParseLang(testCase.S)
+		if err != nil || result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d", testCase, result)
+		}
+	}
+
+	_, err := ParseLang("fr")
+	assert.Error(t, err)
+}
+
+func TestNewDocument(t *testing.T) {
+	box := newDefectBox()
+	box.Run()
+
+	doc := newDocument(box, Japanese)
+	assert.Equal(t, 2, len(doc.Tables))
+	assert.Equal(t, "直接材料費", doc.Tables[0].Name)
+	assert.Equal(t, "定点投入(投入点0) / 平均法 / 度外視法", doc.Tables[0].Method)
+	assert.Equal(t, []string{"月末仕掛品", "500", "500", "500", "200", "100,000"}, doc.Tables[0].Rows[4])
+	assert.Equal(t, "原価要素2", doc.Tables[1].Name)
+
+	doc = newDocument(box, English)
+	assert.Equal(t, "Cost 2", doc.Tables[1].Name)
+	assert.Equal(t, "Uniform input / AVG / Neglecting", doc.Tables[1].Method)
+	assert.Equal(t, "Ending work in process", doc.Tables[0].Rows[4][0])
+	assert.Equal(t, "Equivalent units", doc.Tables[0].Header[2])
+}
@@ -0,0 +1,374 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/boxdiagram"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/costreport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// statementLabels is 言語ごとの問題文の定型文
+var statementLabels = map[Lang]map[string]string{
+	Japanese: {
+		"intro":      "当工場では単一製品を連続生産しており, 単純総合原価計算を採用している。次の資料にもとづいて, 下記の問に答えなさい。",
+		"data":       "[資料]",
+		"production": "1. 生産データ",
+		"costs":      "2. 原価データ",
+		"total":      "合計",
+		"units":      "%s個",
+		"note":       "(注) ( )内は加工進捗度または仕損の発生点を示す。",
+		"start":      "%sは工程の始点で投入している。",
+		"end":        "%sは工程の終点で投入している。",
+		"point":      "%sは工程の%sの地点で投入している。",
+		"uniform":    "%sは工程を通じて平均的に発生している。",
+		"lossEnd":    "%sは工程の終点で発生している。",
+		"lossPoint":  "%sは工程の%sの地点で発生している。",
+		"bear":       "%s費は%sに負担させる。",
+		"cmethod":    "月末仕掛品の評価は%sによること。",
+		"dmethod":    "正常仕損費は%sにより処理すること。",
+		"per":        "%sについては%s",
+		"firstCost":  "月初仕掛品原価",
+		"inputCost":  "当月製造費用",
+		"yen":        "%s円",
+		"question":   "問 %sを計算しなさい。",
+		"answers":    "[解答欄]",
+		"blank":      "円",
+		"blankAvg":   "円/個",
+		"abnormal":   "異常仕損費",
+		"and":        "および",
+		"pair":       "と",
+		"comma":      ", ",
+	},
+	English: {
+		"intro":      "A factory produces a single product continuously and uses process costing. Answer the question below based on the following data.",
+		"data":       "[Data]",
+		"production": "1. Production data",
+		"costs":      "2. Cost data",
+		"total":      "Total",
+		"units":      "%s units",
+		"note":       "Note: Figures in parentheses show the degree of completion, or the point where a loss occurs.",
+		"start":      "%s are added at the beginning of the process.",
+		"end":        "%s are added at the end of the process.",
+		"point":      "%s are added at the %s point of the process.",
+		"uniform":    "%s are incurred uniformly throughout the process.",
+		"lossEnd":    "%s occurs at the end of the process.",
+		"lossPoint":  "%s occurs at the %s point of the process.",
+		"bear":       "The cost of %s is borne by %s.",
+		"cmethod":    "Ending work in process is valued by %s.",
+		"dmethod":    "The cost of normal loss is handled by %s.",
+		"per":        "%[2]s for %[1]s",
+		"firstCost":  "Beginning work in process",
+		"inputCost":  "Costs added this month",
+		"yen":        "¥%s",
+		"question":   "Question: Calculate %s.",
+		"answers":    "[Answers]",
+		"blank":      "yen",
+		"blankAvg":   "yen per unit",
+		"abnormal":   "Abnormal loss cost",
+		"and":        " and ",
+		"pair":       " and ",
+		"comma":      ", ",
+	},
+}
+
+// methodLabels is 英語の計算方法の名前
+var methodLabels = map[string]string{
+	totalcosting.FIFO.String():          "the first-in, first-out method",
+	totalcosting.AVG.String():           "the weighted average method",
+	totalcosting.Neglecting.String():    "the neglecting method (lost units are left out of equivalent units)",
+	totalcosting.NonNeglecting.String(): "the non-neglecting method (the loss is costed and then allocated)",
+}
+
+// costNames is よく使う原価要素の名前の英語名
+var costNames = map[string]string{
+	"直接材料費": "Direct materials",
+	"材料費":   "Materials",
+	"補助材料費": "Indirect materials",
+	"前工程費":  "Transferred-in costs",
+	"加工費":   "Conversion costs",
+}
+
+// Statement is Boxの物量と原価から日商簿記の形式の問題文を作る
+// Run前のBoxでもよい
+func Statement(box totalcosting.Box, lang Lang) string {
+	l := statementLabels[lang]
+
+	var sb strings.Builder
+	sb.WriteString(l["intro"] + "\n\n" + l["data"] + "\n" + l["production"] + "\n")
+	writeProduction(&sb, box, lang)
+
+	// 投入方法, 仕損の発生点, 計算方法の注記
+	var notes []string
+	for i, c := range box.Costs {
+		name := statementCostName(c, i, lang)
+		switch {
+		case c.InputOnAvg:
+			notes = append(notes, fmt.Sprintf(l["uniform"], name))
+		case c.InputTiming == 0:
+			notes = append(notes, fmt.Sprintf(l["start"], name))
+		case c.InputTiming == 1:
+			notes = append(notes, fmt.Sprintf(l["end"], name))
+		default:
+			notes = append(notes, fmt.Sprintf(l["point"], name, percent(c.InputTiming)))
+		}
+	}
+
+	normalLoss := false
+	for _, e := range box.Master {
+		if e.Type != totalcosting.NormalDefect && e.Type != totalcosting.NormalImpairment &&
+			e.Type != totalcosting.AbnormalDefect && e.Type != totalcosting.AbnormalImpairment {
+			continue
+		}
+
+		name := lang.element(e.Type)
+		if e.Progress == 1 {
+			notes = append(notes, fmt.Sprintf(l["lossEnd"], name))
+		} else {
+			notes = append(notes, fmt.Sprintf(l["lossPoint"], name, percent(e.Progress)))
+		}
+
+		if totalcosting.IsNormalLoss(e.Type) {
+			normalLoss = true
+			notes = append(notes, fmt.Sprintf(l["bear"], lossCostName(e.Type, lang), joinWords(bearers(box, e, lang), lang)))
+		}
+	}
+
+	notes = append(notes, fmt.Sprintf(l["cmethod"], methods(box, lang, func(c totalcosting.Cost) method {
+		return c.CMethod
+	})))
+	if normalLoss {
+		notes = append(notes, fmt.Sprintf(l["dmethod"], methods(box, lang, func(c totalcosting.Cost) method {
+			return c.DMethod
+		})))
+	}
+
+	sb.WriteString("\n  " + l["note"] + "\n")
+	for _, n := range notes {
+		sb.WriteString("  " + n + "\n")
+	}
+
+	sb.WriteString("\n" + l["costs"] + "\n")
+	writeCostData(&sb, box, lang)
+
+	// 問と解答欄
+	answers := []string{
+		lang.label("eotm"),
+		lang.label("product"),
+		lang.label("avg"),
+	}
+	if totalcosting.Index(totalcosting.AbnormalDefect, box.Master) >= 0 ||
+		totalcosting.Index(totalcosting.AbnormalImpairment, box.Master) >= 0 {
+		answers = append(answers, l["abnormal"])
+	}
+
+	question := answers
+	if lang == English {
+		question = make([]string, len(answers))
+		for i, a := range answers {
+			question[i] = strings.ToLower(a)
+		}
+	}
+
+	sb.WriteString("\n" + fmt.Sprintf(l["question"], joinWords(question, lang)) + "\n\n" + l["answers"] + "\n")
+	width := 0
+	for _, a := range answers {
+		if w := boxdiagram.DisplayWidth(a); w > width {
+			width = w
+		}
+	}
+	for i, a := range answers {
+		blank := l["blank"]
+		if i == 2 {
+			blank = l["blankAvg"]
+		}
+		sb.WriteString("  " + boxdiagram.PadRight(a, width) + "  ____________ " + blank + "\n")
+	}
+
+	return sb.String()
+}
+
+// WriteStatement is Boxの問題文を書き出す
+func WriteStatement(w io.Writer, box totalcosting.Box, lang Lang) error {
+	_, err := io.WriteString(w, Statement(box, lang))
+
+	return err
+}
+
+// writeProduction is 生産データを左側, 合計, 右側(完成品は最後)の順に書く
+func writeProduction(sb *strings.Builder, box totalcosting.Box, lang Lang) {
+	l := statementLabels[lang]
+
+	var rows [][]string
+	row := func(name string, e totalcosting.Element, progress bool) {
+		r := []string{name, fmt.Sprintf(l["units"], costreport.FormatYen(float64(e.Unit))), ""}
+		if progress {
+			r[2] = "(" + percent(e.Progress) + ")"
+		}
+		rows = append(rows, r)
+	}
+
+	left := 0
+	for _, e := range box.Master {
+		if e.IsLeftElement() && e.Unit > 0 {
+			row(lang.element(e.Type), e, e.Type == totalcosting.First)
+			left += e.Unit
+		}
+	}
+	row("  "+l["total"], totalcosting.Element{Unit: left}, false)
+
+	for _, e := range box.Master {
+		if !e.IsLeftElement() && e.Type != totalcosting.Output && e.Unit > 0 {
+			row(lang.element(e.Type), e, true)
+		}
+	}
+	if i := totalcosting.Index(totalcosting.Output, box.Master); i >= 0 {
+		row(lang.element(totalcosting.Output), box.Master[i], false)
+	}
+
+	writeColumns(sb, rows)
+}
+
+// writeCostData is 原価要素ごとの月初仕掛品原価と当月製造費用を書く
+func writeCostData(sb *strings.Builder, box totalcosting.Box, lang Lang) {
+	l := statementLabels[lang]
+
+	header := []string{""}
+	first := []string{l["firstCost"]}
+	input := []string{l["inputCost"]}
+	for i, c := range box.Costs {
+		header = append(header, statementCostName(c, i, lang))
+		first = append(first, fmt.Sprintf(l["yen"], costreport.FormatYen(c.FirstCost)))
+		input = append(input, fmt.Sprintf(l["yen"], costreport.FormatYen(c.InputCost)))
+	}
+
+	writeColumns(sb, [][]string{header, first, input})
+}
+
+// writeColumns is 1列目を左寄せ, 残りを右寄せにして列をそろえて書く
+func writeColumns(sb *strings.Builder, rows [][]string) {
+	var widths []int
+	for _, r := range rows {
+		for j, s := range r {
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := boxdiagram.DisplayWidth(s); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	for _, r := range rows {
+		cells := make([]string, len(r))
+		for j, s := range r {
+			if j == 0 {
+				cells[j] = boxdiagram.PadRight(s, widths[j])
+			} else {
+				cells[j] = strings.Repeat(" ", widths[j]-boxdiagram.DisplayWidth(s)) + s
+			}
+		}
+		sb.WriteString(strings.TrimRight("  "+strings.Join(cells, "  "), " ") + "\n")
+	}
+}
+
+// bearers is 正常仕損(減損)費を負担する要素の名前を返す
+// 完成品と, 発生点を通過している月末仕掛品, 異常仕損(減損)が負担する
+func bearers(box totalcosting.Box, loss totalcosting.Element, lang Lang) []string {
+	var result []string
+
+	for _, e := range box.Master {
+		if !totalcosting.IsBearer(e.Type) || e.Unit == 0 {
+			continue
+		}
+		if e.Type == totalcosting.Output || e.IsBear(loss.Progress) {
+			result = append(result, lossCostName(e.Type, lang))
+		}
+	}
+
+	return result
+}
+
+// method is 月末仕掛品か正常仕損の計算方法
+type method interface {
+	String() string
+	Label() string
+}
+
+// methods is 原価要素の計算方法を返す
+// 原価要素によって違うときは原価要素ごとに並べる
+func methods(box totalcosting.Box, lang Lang, get func(c totalcosting.Cost) method) string {
+	l := statementLabels[lang]
+
+	label := func(m method) string {
+		if lang == English {
+			return methodLabels[m.String()]
+		}
+		return m.Label()
+	}
+
+	same := true
+	for _, c := range box.Costs {
+		same = same && get(c).String() == get(box.Costs[0]).String()
+	}
+	if same && len(box.Costs) > 0 {
+		return label(get(box.Costs[0]))
+	}
+
+	var result []string
+	for i, c := range box.Costs {
+		result = append(result, fmt.Sprintf(l["per"], statementCostName(c, i, lang), label(get(c))))
+	}
+
+	return strings.Join(result, l["comma"])
+}
+
+// statementCostName is 原価要素の名前を返す
+// 英語ではよく使う名前を訳す
+func statementCostName(c totalcosting.Cost, i int, lang Lang) string {
+	if c.Name == "" {
+		return fmt.Sprintf(lang.label("cost"), i+1)
+	}
+	if lang == English {
+		if name, ok := costNames[c.Name]; ok {
+			return name
+		}
+	}
+
+	return c.Name
+}
+
+// lossCostName is 文中で使う要素の名前を返す
+// 英語では小文字にする
+func lossCostName(t totalcosting.ElementType, lang Lang) string {
+	if lang == English {
+		return strings.ToLower(lang.element(t))
+	}
+
+	return t.Label()
+}
+
+// joinWords is 語を「A, BおよびC」の形でつなぐ
+func joinWords(words []string, lang Lang) string {
+	l := statementLabels[lang]
+
+	switch len(words) {
+	case 0:
+		return ""
+	case 1:
+		return words[0]
+	case 2:
+		return words[0] + l["pair"] + words[1]
+	}
+
+	return strings.Join(words[:len(words)-1], l["comma"]) + l["and"] + words[len(words)-1]
+}
+
+// percent is 加工進捗度を百分率の文字列にする
+func percent(p float64) string {
+	return strconv.FormatFloat(math.Round(p*10000)/100, 'f', -1, 64) + "%"
+}
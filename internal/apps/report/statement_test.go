@@ -0,0 +1,128 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatement(t *testing.T) {
+	expected := `当工場では単一製品を連続生産しており, 単純総合原価計算を採用している。次の資料にもとづいて, 下記の問に答えなさい。
+
+[資料]
+1. 生産データ
+  月初仕掛品    400個  (50%)
+  当月投入    2,000個
+    合計      2,400個
+  正常仕損      100個  (40%)
+  月末仕掛品    500個  (60%)
+  完成品      1,800個
+
+  (注) ( )内は加工進捗度または仕損の発生点を示す。
+  直接材料費は工程の始点で投入している。
+  原価要素2は工程を通じて平均的に発生している。
+  正常仕損は工程の40%の地点で発生している。
+  正常仕損費は完成品と月末仕掛品に負担させる。
+  月末仕掛品の評価は平均法によること。
+  正常仕損費は度外視法により処理すること。
+
+2. 原価データ
+                  直接材料費  原価要素2
+  月初仕掛品原価    80,000円   60,000円
+  当月製造費用     380,000円  954,000円
+
+問 月末仕掛品原価, 完成品原価および完成品単位原価を計算しなさい。
+
+[解答欄]
+  月末仕掛品原価  ____________ 円
+  完成品原価      ____________ 円
+  完成品単位原価  ____________ 円/個
+`
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteStatement(&buf, newDefectBox(), Japanese))
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestStatementVariations(t *testing.T) {
+	testCases := []struct {
+		Lang     Lang
+		Modify   func(box *totalcosting.Box)
+		Contains []string
+	}{
+		{Japanese, func(box *totalcosting.Box) {
+			box.Master[3].Progress = 1
+			box.Costs[0].InputTiming = 0.5
+			box.Costs[1].CMethod = totalcosting.FIFO
+		}, []string{
+			"直接材料費は工程の50%の地点で投入している。",
+			"正常仕損は工程の終点で発生している。\n  正常仕損費は完成品に負担させる。",
+			"月末仕掛品の評価は直接材料費については平均法, 原価要素2については先入先出法によること。",
+		}},
+		{Japanese, func(box *totalcosting.Box) {
+			box.Master[1].Unit += 20
+			box.Master = append(box.Master, totalcosting.Element{Type: totalcosting.AbnormalDefect, Unit: 20, Progress: 0.8})
+		}, []string{
+			"異常仕損       20個  (80%)",
+			"正常仕損費は完成品, 月末仕掛品および異常仕損に負担させる。",
+			"問 月末仕掛品原価, 完成品原価, 完成品単位原価および異常仕損費を計算しなさい。",
+			"  異常仕損費      ____________ 円\n",
+		}},
+		{Japanese, func(box *totalcosting.Box) {
+			box.Master = []totalcosting.Element{
+				{Type: totalcosting.Input, Unit: 1000},
+				{Type: totalcosting.Output, Unit: 800},
+				{Type: totalcosting.Last, Unit: 200, Progress: 0.25},
+			}
+		}, []string{
+			"  当月投入    1,000個\n    合計      1,000個\n  月末仕掛品    200個  (25%)\n  完成品        800個\n",
+			"月末仕掛品の評価は平均法によること。\n\n",
+		}},
+		{English, func(box *totalcosting.Box) {}, []string{
+			"  Beginning work in process    400 units  (50%)\n",
+			"Direct materials are added at the beginning of the process.",
+			"Cost 2 are incurred uniformly throughout the process.",
+			"The cost of normal defect is borne by completed and ending work in process.",
+			"Ending work in process is valued by the weighted average method.",
+			"The cost of normal loss is handled by the neglecting method",
+			"  Costs added this month             ¥380,000  ¥954,000\n",
+			"Question: Calculate ending work in process, cost of goods completed and unit cost of goods completed.",
+			"  Unit cost of goods completed  ____________ yen per unit\n",
+		}},
+	}
+
+	for _, testCase := range testCases {
+		box := newDefectBox()
+		testCase.Modify(&box)
+		result := Statement(box, testCase.Lang)
+
+		for _, s := range testCase.Contains {
+			if !assert.Contains(t, result, s) {
+				t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+			}
+		}
+	}
+}
+
+func TestJoinWords(t *testing.T) {
+	testCases := []struct {
+		Words  []string
+		Lang   Lang
+		Result string
+	}{
+		{nil, Japanese, ""},
+		{[]string{"A"}, Japanese, "A"},
+		{[]string{"A", "B"}, Japanese, "AとB"},
+		{[]string{"A", "B", "C"}, Japanese, "A, BおよびC"},
+		{[]string{"A", "B", "C"}, English, "A, B and C"},
+	}
+
+	for _, testCase := range testCases {
+		result := joinWords(testCase.Words, testCase.Lang)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package totalcosting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// elementTypeNames is ElementTypeの英語名と日本語名
+var elementTypeNames = []struct {
+	Name  string
+	Label string
+}{
+	First:              {"First", "月初仕掛品"},
+	Input:              {"Input", "当月投入"},
+	Output:             {"Output", "完成品"},
+	Last:               {"Last", "月末仕掛品"},
+	NormalDefect:       {"NormalDefect", "正常仕損"},
+	AbnormalDefect:     {"AbnormalDefect", "異常仕損"},
+	NormalImpairment:   {"NormalImpairment", "正常減損"},
+	AbnormalImpairment: {"AbnormalImpairment", "異常減損"},
+}
+
+// calculationMethodNames is CalculationMethodの英語名と日本語名
+var calculationMethodNames = []struct {
+	Name  string
+	Label string
+}{
+	FIFO: {"FIFO", "先入先出法"},
+	AVG:  {"AVG", "平均法"},
+}
+
+// defectiveProductMethodNames is DefectiveProductMethodの英語名と日本語名
+var defectiveProductMethodNames = []struct {
+	Name  string
+	Label string
+}{
+	Neglecting:    {"Neglecting", "度外視法"},
+	NonNeglecting: {"NonNeglecting", "非度外視法"},
+}
+
+// String is ElementTypeの英語名を返す
+func (t ElementType) String() string {
+	if t < 0 || int(t) >= len(elementTypeNames) {
+		return fmt.Sprintf("ElementType(%d)", int(t))
+	}
+
+	return elementTypeNames[t].Name
+}
+
+// Label is ElementTypeの日本語名を返す
+func (t ElementType) Label() string {
+	if t < 0 || int(t) >= len(elementTypeNames) {
+		return t.String()
+	}
+
+	return elementTypeNames[t].Label
+}
+
+// MarshalText is ElementTypeを英語名で書き出す
+func (t ElementType) MarshalText() ([]byte, error) {
+	if t < 0 || int(t) >= len(elementTypeNames) {
+		return nil, fmt.Errorf("totalcosting: invalid element type %d", int(t))
+	}
+
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText is 英語名または日本語名からElementTypeを読み込む
+func (t *ElementType) UnmarshalText(text []byte) error {
+	for i, n := range elementTypeNames {
+		if string(text) == n.Name || string(text) == n.Label {
+			*t = ElementType(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("totalcosting: unknown element type %q", text)
+}
+
+// String is CalculationMethodの英語名を返す
+func (m CalculationMethod) String() string {
+	if m < 0 || int(m) >= len(calculationMethodNames) {
+		return fmt.Sprintf("CalculationMethod(%d)", int(m))
+	}
+
+	return calculationMethodNames[m].Name
+}
+
+// Label is CalculationMethodの日本語名を返す
+func (m CalculationMethod) Label() string {
+	if m < 0 || int(m) >= len(calculationMethodNames) {
+		return m.String()
+	}
+
+	return calculationMethodNames[m].Label
+}
+
+// MarshalText is CalculationMethodを英語名で書き出す
+func (m CalculationMethod) MarshalText() ([]byte, error) {
+	if m < 0 || int(m) >= len(calculationMethodNames) {
+		return nil, fmt.Errorf("totalcosting: invalid calculation method %d", int(m))
+	}
+
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText is 英語名または日本語名からCalculationMethodを読み込む
+func (m *CalculationMethod) UnmarshalText(text []byte) error {
+	for i, n := range calculationMethodNames {
+		if string(text) == n.Name || string(text) == n.Label {
+			*m = CalculationMethod(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("totalcosting: unknown calculation method %q", text)
+}
+
+// String is DefectiveProductMethodの英語名を返す
+func (m DefectiveProductMethod) String() string {
+	if m < 0 || int(m) >= len(defectiveProductMethodNames) {
+		return fmt.Sprintf("DefectiveProductMethod(%d)", int(m))
+	}
+
+	return defectiveProductMethodNames[m].Name
+}
+
+// Label is DefectiveProductMethodの日本語名を返す
+func (m DefectiveProductMethod) Label() string {
+	if m < 0 || int(m) >= len(defectiveProductMethodNames) {
+		return m.String()
+	}
+
+	return defectiveProductMethodNames[m].Label
+}
+
+// MarshalText is DefectiveProductMethodを英語名で書き出す
+func (m DefectiveProductMethod) MarshalText() ([]byte, error) {
+	if m < 0 || int(m) >= len(defectiveProductMethodNames) {
+		return nil, fmt.Errorf("totalcosting: invalid defective product method %d", int(m))
+	}
+
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText is 英語名または日本語名からDefectiveProductMethodを読み込む
+func (m *DefectiveProductMethod) UnmarshalText(text []byte) error {
+	for i, n := range defectiveProductMethodNames {
+		if string(text) == n.Name || string(text) == n.Label {
+			*m = DefectiveProductMethod(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("totalcosting: unknown defective product method %q", text)
+}
+
+// DecodeBox is JSONからBoxを読み込む
+// 未知のフィールドや余分なデータがあればエラー
+func DecodeBox(r io.Reader) (Box, error) {
+	var box Box
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&box); err != nil {
+		return Box{}, fmt.Errorf("totalcosting: decode box: %w", err)
+	}
+
+	if decoder.More() {
+		return Box{}, fmt.Errorf("totalcosting: decode box: unexpected data after box")
+	}
+
+	return box, nil
+}
+
+// EncodeBox is BoxをインデントしたJSONで書き出す
+func EncodeBox(w io.Writer, box Box) error {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(box); err != nil {
+		return fmt.Errorf("totalcosting: encode box: %w", err)
+	}
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
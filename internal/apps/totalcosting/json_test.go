@@ -0,0 +1,89 @@
+package totalcosting
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElementTypeString(t *testing.T) {
+	testCases := []struct {
+		T     ElementType
+		Name  string
+		Label string
+	}{
+		{First, "First", "月初仕掛品"},
+		{Output, "Output", "完成品"},
+		{AbnormalImpairment, "AbnormalImpairment", "異常減損"},
+		{ElementType(99), "ElementType(99)", "ElementType(99)"},
+	}
+
+	for _, testCase := range testCases {
+		if testCase.T.String() != testCase.Name || testCase.T.Label() != testCase.Label {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s %s", testCase, testCase.T.String(), testCase.T.Label())
+		}
+	}
+
+	assert.Equal(t, "FIFO", FIFO.String())
+	assert.Equal(t, "平均法", AVG.Label())
+	assert.Equal(t, "NonNeglecting", NonNeglecting.String())
+	assert.Equal(t, "度外視法", Neglecting.Label())
+}
+
+func TestDecodeBox(t *testing.T) {
+	input := `{
+  "master": [
+    {"type": "月初仕掛品", "unit": 300, "progress": 0.6},
+    {"type": "Input", "unit": 1380},
+    {"type": "完成品", "unit": 1440},
+    {"type": "Last", "unit": 240, "progress": 0.3}
+  ],
+  "costs": [
+    {"input_on_avg": false, "input_timing": 0, "calculation_method": "平均法",
+     "defective_product_method": "NonNeglecting", "first_cost": 206400, "input_cost": 717600},
+    {"input_on_avg": true, "calculation_method": "AVG",
+     "defective_product_method": "非度外視法", "first_cost": 161640, "input_cost": 972360}
+  ]
+}`
+
+	box, err := DecodeBox(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, newTestBox(), box)
+
+	box.Run()
+	assert.Equal(t, 1300.0, box.ProductAvgCost)
+}
+
+func TestDecodeBoxError(t *testing.T) {
+	testCases := []string{
+		`{"master": [{"type": "Unknown", "unit": 1}]}`,
+		`{"master": [{"type": 2, "unit": 1}]}`,
+		`{"master": [], "costs": [{"calculation_method": "LIFO"}]}`,
+		`{"master": [], "extra": true}`,
+		`{"master": []} {"master": []}`,
+	}
+
+	for _, testCase := range testCases {
+		_, err := DecodeBox(strings.NewReader(testCase))
+		if err == nil {
+			t.Errorf("Invalid result. testCase:%s, expected error", testCase)
+		}
+	}
+}
+
+func TestEncodeBox(t *testing.T) {
+	box := newTestBox()
+	box.Run()
+
+	var buf bytes.Buffer
+	assert.NoError(t, EncodeBox(&buf, box))
+	assert.Contains(t, buf.String(), `"type": "Output"`)
+	assert.Contains(t, buf.String(), `"calculation_method": "AVG"`)
+	assert.Contains(t, buf.String(), `"defective_product_method": "NonNeglecting"`)
+
+	decoded, err := DecodeBox(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, box, decoded)
+}
@@ -0,0 +1,160 @@
+package totalcosting
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 月次の繰越で発生するエラー
+var (
+	ErrPeriodLocked    = errors.New("totalcosting: period is locked")
+	ErrPeriodNotLocked = errors.New("totalcosting: previous period is not locked")
+	ErrPeriodNotFound  = errors.New("totalcosting: period not found")
+)
+
+// GetLastCost is 月末仕掛品原価を返す
+// Run後に呼ぶこと
+func (c Cost) GetLastCost() float64 {
+	total := 0.0
+
+	for _, e := range c.Elements {
+		if e.Type == Last {
+			total += e.Cost()
+		}
+	}
+
+	return total
+}
+
+// Clone is 計算しても元のBoxが変わらないように複製する
+func (b Box) Clone() Box {
+	result := b
+	result.Master = append([]Element(nil), b.Master...)
+	result.Trace = append(Trace(nil), b.Trace...)
+	result.Costs = make([]Cost, len(b.Costs))
+
+	for i, c := range b.Costs {
+		c.Elements = append([]Element(nil), c.Elements...)
+		result.Costs[i] = c
+	}
+
+	return result
+}
+
+// CarryForward is 月末仕掛品をnextの月初仕掛品として繰り越す
+// bはRun済みであること
+// nextのMasterに月初仕掛品がなければ先頭に追加する
+func (b Box) CarryForward(next *Box) error {
+	if len(b.Costs) != len(next.Costs) {
+		return fmt.Errorf("totalcosting: cost count mismatch: %d and %d", len(b.Costs), len(next.Costs))
+	}
+
+	first := Element{Type: First}
+	if i := Index(Last, b.Master); i >= 0 {
+		first.Unit = b.Master[i].Unit
+		first.Progress = b.Master[i].Progress
+	}
+
+	// 呼び出し元のMasterを書き換えないように複製する
+	master := make([]Element, len(next.Master))
+	copy(master, next.Master)
+
+	if i := Index(First, master); i >= 0 {
+		master[i] = first
+	} else {
+		master = append([]Element{first}, master...)
+	}
+	next.Master = master
+
+	// Costsも呼び出し元と共有しないように複製する
+	costs := make([]Cost, len(next.Costs))
+	copy(costs, next.Costs)
+
+	for i := range costs {
+		costs[i].FirstCost = b.Costs[i].GetLastCost()
+	}
+	next.Costs = costs
+
+	return nil
+}
+
+// Period is 1か月分の計算結果
+type Period struct {
+	Name   string // 会計期間の名前(例: 2021-04)
+	Box    Box
+	Locked bool
+}
+
+// PeriodLedger is 工程ごとの月次計算の履歴
+type PeriodLedger struct {
+	Process string
+	Periods []Period
+}
+
+// Run is nameの月のBoxを計算して履歴に記録する
+// 前月の月末仕掛品を月初仕掛品として繰り越してから計算する
+// 同じ月を再計算できるのはロック前のみ
+// 新しい月を計算するには前月がロックされていること
+func (l *PeriodLedger) Run(name string, b Box) (Box, error) {
+	index := l.index(name)
+
+	if index >= 0 && l.Periods[index].Locked {
+		return Box{}, ErrPeriodLocked
+	}
+
+	if index < 0 {
+		if n := len(l.Periods); n > 0 && !l.Periods[n-1].Locked {
+			return Box{}, ErrPeriodNotLocked
+		}
+		index = len(l.Periods)
+		l.Periods = append(l.Periods, Period{Name: name})
+	}
+
+	// 呼び出し元のBoxを書き換えないように複製する
+	b = b.Clone()
+
+	if index > 0 {
+		if err := l.Periods[index-1].Box.CarryForward(&b); err != nil {
+			return Box{}, err
+		}
+	}
+
+	b.Run()
+	l.Periods[index].Box = b
+
+	return b, nil
+}
+
+// Lock is nameの月を確定して変更できないようにする
+func (l *PeriodLedger) Lock(name string) error {
+	index := l.index(name)
+	if index < 0 {
+		return ErrPeriodNotFound
+	}
+
+	l.Periods[index].Locked = true
+
+	return nil
+}
+
+// Get is nameの月の計算結果を返す
+func (l PeriodLedger) Get(name string) (Period, error) {
+	index := l.index(name)
+	if index < 0 {
+		return Period{}, ErrPeriodNotFound
+	}
+
+	return l.Periods[index], nil
+}
+
+// index is nameの月の位置を返す
+// 見つからなければ-1を返す
+func (l PeriodLedger) index(name string) int {
+	for i := 0; i < len(l.Periods); i++ {
+		if l.Periods[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
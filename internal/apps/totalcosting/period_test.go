@@ -0,0 +1,122 @@
+package totalcosting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBox() Box {
+	var box Box
+
+	box.Master = []Element{
+		{Type: First, Unit: 300, Progress: 0.6},
+		{Type: Input, Unit: 1380},
+		{Type: Output, Unit: 1440},
+		{Type: Last, Unit: 240, Progress: 0.3},
+	}
+
+	box.Costs = []Cost{
+		{
+			InputOnAvg:  false,
+			InputTiming: 0.0,
+			CMethod:     AVG,
+			DMethod:     NonNeglecting,
+			FirstCost:   206400,
+			InputCost:   717600,
+		},
+		{
+			InputOnAvg: true,
+			CMethod:    AVG,
+			DMethod:    NonNeglecting,
+			FirstCost:  161640,
+			InputCost:  972360,
+		},
+	}
+
+	return box
+}
+
+func TestCarryForward(t *testing.T) {
+	box := newTestBox()
+	box.Run()
+
+	next := newTestBox()
+	next.Master = next.Master[1:]
+
+	err := box.CarryForward(&next)
+	assert.NoError(t, err)
+
+	assert.Equal(t, Element{Type: First, Unit: 240, Progress: 0.3}, next.Master[0])
+	assert.Equal(t, 132000.0, next.Costs[0].FirstCost)
+	assert.Equal(t, 54000.0, next.Costs[1].FirstCost)
+	assert.Equal(t, box.EOTMTotalCost, next.Costs[0].FirstCost+next.Costs[1].FirstCost)
+}
+
+func TestCarryForwardMismatch(t *testing.T) {
+	box := newTestBox()
+	box.Run()
+
+	next := newTestBox()
+	next.Costs = next.Costs[:1]
+
+	err := box.CarryForward(&next)
+	assert.Error(t, err)
+}
+
+func TestPeriodLedger(t *testing.T) {
+	ledger := PeriodLedger{Process: "第1工程"}
+
+	april, err := ledger.Run("2021-04", newTestBox())
+	assert.NoError(t, err)
+	assert.Equal(t, 1300.0, april.ProductAvgCost)
+
+	// 前月がロックされていなければ翌月は計算できない
+	_, err = ledger.Run("2021-05", newTestBox())
+	assert.ErrorIs(t, err, ErrPeriodNotLocked)
+
+	assert.NoError(t, ledger.Lock("2021-04"))
+
+	// ロックした月は再計算できない
+	_, err = ledger.Run("2021-04", newTestBox())
+	assert.ErrorIs(t, err, ErrPeriodLocked)
+
+	may, err := ledger.Run("2021-05", newTestBox())
+	assert.NoError(t, err)
+	assert.Equal(t, 240, may.Master[0].Unit)
+	assert.Equal(t, 132000.0, may.Costs[0].FirstCost)
+
+	period, err := ledger.Get("2021-05")
+	assert.NoError(t, err)
+	assert.False(t, period.Locked)
+	assert.Equal(t, 2, len(ledger.Periods))
+
+	_, err = ledger.Get("2021-06")
+	assert.ErrorIs(t, err, ErrPeriodNotFound)
+}
+
+func TestPeriodLedgerKeepsInput(t *testing.T) {
+	ledger := PeriodLedger{Process: "第1工程"}
+	box := newTestBox()
+
+	_, err := ledger.Run("2021-04", box)
+	assert.NoError(t, err)
+
+	// 呼び出し元のBoxは計算前のまま
+	assert.Equal(t, newTestBox(), box)
+}
+
+func TestClone(t *testing.T) {
+	box := newTestBox()
+	box.Run()
+
+	clone := box.Clone()
+	assert.Equal(t, box, clone)
+
+	clone.Master[0].Unit = 0
+	clone.Costs[0].Elements[0].Unit = 0
+	clone.Costs[0].FirstCost = 0
+	assert.NotEqual(t, 0, box.Master[0].Unit)
+	assert.NotEqual(t, 0, box.Costs[0].Elements[0].Unit)
+	assert.NotEqual(t, 0.0, box.Costs[0].FirstCost)
+}
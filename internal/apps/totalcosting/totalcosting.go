@@ -1,5 +1,11 @@
 package totalcosting
 
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
 // ElementType はBox図の要素の種別を表す
 type ElementType int
 
@@ -18,11 +24,11 @@ const (
 
 // Element はBOX図の構成要素を想定
 type Element struct {
-	Type     ElementType // 種別
-	Price    float64     // 単価
-	Unit     int         // 数量
-	Progress float64     // 加工進捗度
-	NDBurden int         // 正常仕損の負担量
+	Type     ElementType `json:"type" yaml:"type"`                               // 種別
+	Price    float64     `json:"price,omitempty" yaml:"price,omitempty"`         // 単価
+	Unit     int         `json:"unit" yaml:"unit"`                               // 数量
+	Progress float64     `json:"progress,omitempty" yaml:"progress,omitempty"`   // 加工進捗度
+	NDBurden int         `json:"nd_burden,omitempty" yaml:"nd_burden,omitempty"` // 正常仕損の負担量
 }
 
 // IsLeftElement is ElementTypeがBox図左側の要素かを確認する
@@ -76,17 +82,20 @@ const (
 
 // Cost is 仕掛品のBOX図
 type Cost struct {
-	InputOnAvg  bool
-	InputTiming float64
-	Elements    []Element
-	CMethod     CalculationMethod
-	DMethod     DefectiveProductMethod
-	FirstCost   float64
-	InputCost   float64
+	Name        string                 `json:"name,omitempty" yaml:"name,omitempty"`
+	InputOnAvg  bool                   `json:"input_on_avg" yaml:"input_on_avg"`
+	InputTiming float64                `json:"input_timing" yaml:"input_timing"`
+	Elements    []Element              `json:"elements,omitempty" yaml:"elements,omitempty"`
+	CMethod     CalculationMethod      `json:"calculation_method" yaml:"calculation_method"`
+	DMethod     DefectiveProductMethod `json:"defective_product_method" yaml:"defective_product_method"`
+	FirstCost   float64                `json:"first_cost" yaml:"first_cost"`
+	InputCost   float64                `json:"input_cost" yaml:"input_cost"`
 }
 
 // CalulateInputUnit is 投入点と進捗度を比較して、進捗度が投入点以上なら投入
 func (c *Cost) CalulateInputUnit(master []Element) {
+	sumFirst := 0
+	sumRight := 0
 	c.Elements = make([]Element, len(master))
 
 	for i, m := range master {
@@ -114,7 +123,25 @@ func (c *Cost) CalulateInputUnit(master []Element) {
 			}
 		}
 
+		// 投入点に達していない要素にはまだ投入されていない
+		if element.Type != Input && element.Progress < c.InputTiming {
+			element.Unit = 0
+		}
+
 		c.Elements[i] = element
+
+		// 投入量計算のための処理
+		if element.Type == First {
+			sumFirst += element.Unit
+		} else if !element.IsLeftElement() {
+			sumRight += element.Unit
+		}
+	}
+
+	for i := 0; i < len(c.Elements); i++ {
+		if c.Elements[i].Type == Input {
+			c.Elements[i].Unit = sumRight - sumFirst
+		}
 	}
 }
 
@@ -141,7 +168,7 @@ func (c *Cost) CalulateConversionUnit(master []Element) {
 			element.Unit = m.Unit
 		} else {
 			element.Progress = m.Progress
-			element.Unit = int(float64(m.Unit) * m.Progress)
+			element.Unit = int(math.Round(float64(m.Unit) * m.Progress))
 		}
 
 		c.Elements[i] = element
@@ -240,11 +267,12 @@ func (c Cost) GetTotalNDBurden() int {
 
 // Box is 解く問題
 type Box struct {
-	Master           []Element
-	Costs            []Cost
-	ProductTotalCost float64
-	ProductAvgCost   float64
-	EOTMTotalCost    float64
+	Master           []Element `json:"master" yaml:"master"`
+	Costs            []Cost    `json:"costs" yaml:"costs"`
+	ProductTotalCost float64   `json:"product_total_cost" yaml:"product_total_cost"`
+	ProductAvgCost   float64   `json:"product_avg_cost" yaml:"product_avg_cost"`
+	EOTMTotalCost    float64   `json:"eotm_total_cost" yaml:"eotm_total_cost"`
+	Trace            Trace     `json:"trace,omitempty" yaml:"trace,omitempty"`
 }
 
 // CalculationEOFMCost is 月末仕掛品原価の計算
@@ -278,9 +306,10 @@ func (b Box) CalculationProductCost() float64 {
 }
 
 // CalculationProductAvgCost is 完成品単位原価の計算
+// 完成品がなければ0を返す
 func (b Box) CalculationProductAvgCost() float64 {
 	for _, e := range b.Master {
-		if e.Type == Output {
+		if e.Type == Output && e.Unit > 0 {
 			return b.ProductTotalCost / float64(e.Unit)
 		}
 	}
@@ -288,167 +317,454 @@ func (b Box) CalculationProductAvgCost() float64 {
 	return 0.0
 }
 
+// CostName is i番目の原価要素の名前を返す
+// 名前がなければ「原価要素1」のように1から数えた番号で呼ぶ
+func (b Box) CostName(i int) string {
+	if name := b.Costs[i].Name; name != "" {
+		return name
+	}
+
+	return "原価要素" + strconv.Itoa(i+1)
+}
+
 // Run is culcurate answer
+// 計算過程はTraceに記録する
 func (b *Box) Run() {
-	// 数量の計算
-	cCount := len(b.Costs)
-	for i := 0; i < cCount; i++ {
-		// 定点で投入
-		if !b.Costs[i].InputOnAvg {
-			// 投入量の計算
-			b.Costs[i].CalulateInputUnit(b.Master)
+	b.Trace = nil
+
+	for i := 0; i < len(b.Costs); i++ {
+		// 数量の計算
+		b.runUnit(i)
+
+		// 単価の計算と月末仕掛品原価, 完成品原価の計算
+		b.runPrice(i)
+
+		// 正常仕損の扱い
+		for j := 0; j < len(b.Costs[i].Elements); j++ {
+			if IsNormalLoss(b.Costs[i].Elements[j].Type) {
+				b.runNormalLoss(i, j)
+			}
 		}
+	}
+
+	// 月末仕掛品原価の計算
+	b.EOTMTotalCost = b.CalculationEOFMCost()
+	b.record(Step{
+		Cost:       -1,
+		Stage:      StageTotal,
+		Key:        "eotm_total_cost",
+		Formula:    "各原価要素の月末仕掛品原価の合計",
+		Expression: b.joinCost(Last),
+		Value:      b.EOTMTotalCost,
+	})
+
+	// 完成品原価の計算
+	b.ProductTotalCost = b.CalculationProductCost()
+	b.record(Step{
+		Cost:       -1,
+		Stage:      StageTotal,
+		Key:        "product_total_cost",
+		Formula:    "各原価要素の完成品原価の合計",
+		Expression: b.joinCost(Output),
+		Value:      b.ProductTotalCost,
+	})
+
+	// 完成品単位原価の計算
+	b.ProductAvgCost = b.CalculationProductAvgCost()
+	if i := Index(Output, b.Master); i >= 0 {
+		b.record(Step{
+			Cost:       -1,
+			Stage:      StageTotal,
+			Key:        "product_avg_cost",
+			Formula:    "完成品原価 ÷ 完成品数量",
+			Expression: formatNumber(b.ProductTotalCost) + " ÷ " + formatNumber(float64(b.Master[i].Unit)),
+			Value:      b.ProductAvgCost,
+		})
+	}
+}
+
+// runUnit is i番目のCostの完成品換算量を計算する
+func (b *Box) runUnit(i int) {
+	c := &b.Costs[i]
 
+	var branch string
+	if c.InputOnAvg {
 		// 平均的に投入
-		if b.Costs[i].InputOnAvg {
-			// 完成品換算量を計算
-			b.Costs[i].CalulateConversionUnit(b.Master)
-		}
+		branch = "平均的投入"
+		c.CalulateConversionUnit(b.Master)
+	} else {
+		// 定点で投入
+		branch = "定点投入(投入点" + formatNumber(c.InputTiming) + ")"
+		c.CalulateInputUnit(b.Master)
 	}
 
-	// 正常仕損の扱い
-	for i := 0; i < cCount; i++ {
-		normalDefectProgress := GetNormalDefectProgress(b.Costs[i].Elements)
+	for j, e := range c.Elements {
+		if e.Type == Input {
+			continue
+		}
 
-		// 度外視法
-		if b.Costs[i].DMethod == Neglecting {
-			// 月末仕掛品進捗度が正常仕損発生点を超えていれば両者負担
-			for j := 0; j < len(b.Costs[i].Elements); j++ {
-				elementType := b.Costs[i].Elements[j].Type
+		m := b.Master[j]
+		step := Step{
+			Cost:    i,
+			Stage:   StageUnit,
+			Branch:  branch,
+			Key:     costKey(i, "units", e.Type),
+			Element: e.Type.Label(),
+			Value:   float64(e.Unit),
+		}
 
-				// 完成品負担割合の計算
-				if elementType == Output {
-					// 先入先出法の場合
-					if b.Costs[i].CMethod == FIFO {
-						b.Costs[i].Elements[j].NDBurden = b.Costs[i].GetFIFOOutputBurder()
-					}
+		switch {
+		case e.Type == Output:
+			step.Formula = "完成品数量"
+			step.Expression = formatNumber(float64(m.Unit))
+		case c.InputOnAvg:
+			step.Formula = "数量 × 加工進捗度"
+			step.Expression = formatNumber(float64(m.Unit)) + " × " + formatNumber(m.Progress)
+		case e.Progress < c.InputTiming:
+			step.Formula = "投入点に達していないので0"
+			step.Expression = formatNumber(m.Progress) + " < " + formatNumber(c.InputTiming)
+		default:
+			step.Formula = "投入点を通過しているので数量"
+			step.Expression = formatNumber(float64(m.Unit))
+		}
 
-					// 平均法の場合
-					if b.Costs[i].CMethod == AVG {
-						b.Costs[i].Elements[j].NDBurden = b.Costs[i].Elements[j].Unit
-					}
-				}
+		b.record(step)
+	}
 
-				// 月末仕掛品負担割合の計算
-				if elementType != Last {
-					if b.Costs[i].Elements[j].Progress > normalDefectProgress {
-						// 正常仕損発生点を超えているので負担
-						b.Costs[i].Elements[j].NDBurden = b.Costs[i].Elements[j].Unit
-
-						// 両者負担の場合は投入量から正常仕損量を控除
-						for k := 0; k < len(b.Costs[i].Elements); k++ {
-							if b.Costs[i].Elements[k].Type == Input {
-								b.Costs[i].Elements[k].Unit -= b.Costs[i].Elements[j].Unit
-							}
-						}
-					} else {
-						b.Costs[i].Elements[j].NDBurden = 0
-					}
-				}
+	if j := Index(Input, c.Elements); j >= 0 {
+		var right []string
+		first := "0"
+		formula := "Box図右側の合計 - 月初仕掛品"
+
+		// 度外視法では正常仕損を除いた換算量を当月投入とする
+		unit := c.Elements[j].Unit
+		loss := b.neglectedLoss(i)
+		if loss >= 0 {
+			formula = "Box図右側の正常仕損以外の合計 - 月初仕掛品"
+			unit -= loss
+		}
+
+		for _, e := range c.Elements {
+			if e.Type == First {
+				first = formatNumber(float64(e.Unit))
+			} else if !e.IsLeftElement() && !(loss >= 0 && IsNormalLoss(e.Type)) {
+				right = append(right, formatNumber(float64(e.Unit)))
 			}
 		}
 
-		// 非度外視法
-		if b.Costs[i].DMethod == Neglecting {
-			// 月末仕掛品進捗度が正常仕損発生点を超えていれば両者負担
-			for j := 0; j < len(b.Costs[i].Elements); j++ {
-				elementType := b.Costs[i].Elements[j].Type
+		b.record(Step{
+			Cost:       i,
+			Stage:      StageUnit,
+			Branch:     branch,
+			Key:        costKey(i, "units", Input),
+			Element:    Input.Label(),
+			Formula:    formula,
+			Expression: "(" + strings.Join(right, " + ") + ") - " + first,
+			Value:      float64(unit),
+		})
+	}
+}
 
-				if elementType != Output && elementType != Last {
-					continue
-				}
+// runPrice is i番目のCostの単価を計算して完成品以外に配分し, 差額を完成品原価とする
+// 度外視法で正常仕損を無視できる場合, 計算過程には正常仕損を除いた換算量で計算した単価と原価を記録する
+func (b *Box) runPrice(i int) {
+	c := &b.Costs[i]
+	step := Step{Cost: i, Stage: StagePrice, Key: costKey(i, "price", -1)}
+
+	loss := b.neglectedLoss(i)
+	neglected := loss >= 0
+	if !neglected {
+		loss = 0
+	}
 
-				if b.Costs[i].Elements[j].Progress > normalDefectProgress {
-					// 正常仕損発生点を超えているので負担
-					if b.Costs[i].CMethod == FIFO {
-						// 先入先出法では完成品の負担量の計算に注意
-						if elementType == Output {
-							// 投入量から月末仕掛品量と正常仕損量を差し引いたのが
-							// 完成品の負担量
-							b.Costs[i].Elements[j].NDBurden = b.Costs[i].GetFIFOOutputBurder()
-						}
-						if elementType == Last {
-							b.Costs[i].Elements[j].NDBurden = b.Costs[i].Elements[j].Unit
-						}
-					}
-					if b.Costs[i].CMethod == AVG {
-						// 平均法では負担量=仕掛品量
-						b.Costs[i].Elements[j].NDBurden = b.Costs[i].Elements[j].Unit
-					}
-				} else {
-					if elementType == Output {
-						// 完成品が全部負担
-						b.Costs[i].Elements[j].NDBurden = b.Costs[i].Elements[j].Unit
-					} else {
-						// 正常仕損発生点を超えていないので負担しない
-						b.Costs[i].Elements[j].NDBurden = 0
-					}
+	var price, shown float64
+	if c.CMethod == FIFO {
+		// 先入先出法
+		price = c.GetPriceFIFO()
+		step.Branch = "先入先出法"
+		step.Formula = "当月投入原価 ÷ 当月投入換算量"
+		if j := Index(Input, c.Elements); j >= 0 {
+			unit := c.Elements[j].Unit - loss
+			shown = c.InputCost / float64(unit)
+			step.Expression = formatNumber(c.InputCost) + " ÷ " + formatNumber(float64(unit))
+		}
+	} else {
+		// 平均法
+		price = c.GetPriceAVG()
+		step.Branch = "平均法"
+		step.Formula = "(月初仕掛品原価 + 当月投入原価) ÷ (月初仕掛品換算量 + 当月投入換算量)"
+
+		var units []string
+		total := 0
+		for _, e := range c.Elements {
+			if e.IsLeftElement() {
+				unit := e.Unit
+				if e.Type == Input {
+					unit -= loss
 				}
+				total += unit
+				units = append(units, formatNumber(float64(unit)))
 			}
 		}
+		shown = (c.FirstCost + c.InputCost) / float64(total)
+		step.Expression = "(" + formatNumber(c.FirstCost) + " + " + formatNumber(c.InputCost) +
+			") ÷ (" + strings.Join(units, " + ") + ")"
 	}
 
-	// 月末仕掛品原価の計算
-	for _, c := range b.Costs {
-		// 先入先出法
-		if c.CMethod == FIFO {
-			lastPrice := c.GetPriceFIFO()
-			totalUnit := 0
-
-			// 完成品以外の平均単価を代入
-			for i := 0; i < len(c.Elements); i++ {
-				if c.Elements[i].IsLeftElement() || c.Elements[i].Type == Output {
-					continue
-				}
+	// 換算量が0なら単価も0
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		price = 0.0
+	}
+	if math.IsNaN(shown) || math.IsInf(shown, 0) {
+		shown = 0.0
+	}
 
-				c.Elements[i].Price = lastPrice
-				totalUnit += c.Elements[i].Unit
-			}
+	step.Value = shown
+	b.record(step)
 
-			// 差額で完成品の平均単価を計算
-			for i := 0; i < len(c.Elements); i++ {
-				if c.Elements[i].Type != Output {
-					continue
-				}
+	// 完成品以外に単価を配分
+	others := 0.0
+	shownOthers := 0.0
+	for j := 0; j < len(c.Elements); j++ {
+		e := &c.Elements[j]
+		if e.IsLeftElement() || e.Type == Output {
+			continue
+		}
 
-				totalCost := c.FirstCost + c.InputCost
-				outputCost := totalCost - lastPrice*float64(totalUnit)
-				c.Elements[i].Price = outputCost / float64(c.Elements[i].Unit)
+		e.Price = price
+		others += e.Cost()
+
+		// 正常仕損費は負担先を決めるときに記録する
+		if IsNormalLoss(e.Type) {
+			if !neglected {
+				shownOthers += e.Cost()
 			}
+			continue
 		}
 
-		// 平均法
-		if c.CMethod == AVG {
-			lastPrice := c.GetPriceAVG()
+		cost := shown * float64(e.Unit)
+		shownOthers += cost
+
+		b.record(Step{
+			Cost:       i,
+			Stage:      StageAllocation,
+			Branch:     step.Branch,
+			Key:        costKey(i, "cost", e.Type),
+			Element:    e.Type.Label(),
+			Formula:    "単価 × 換算量",
+			Expression: formatNumber(shown) + " × " + formatNumber(float64(e.Unit)),
+			Value:      cost,
+		})
+	}
 
-			for i := 0; i < len(c.Elements); i++ {
-				if c.Elements[i].IsLeftElement() {
-					continue
-				}
+	// 差額で完成品原価を計算
+	for j := 0; j < len(c.Elements); j++ {
+		e := &c.Elements[j]
+		if e.Type != Output {
+			continue
+		}
 
-				c.Elements[i].Price = lastPrice
-			}
+		totalCost := c.FirstCost + c.InputCost
+		outputCost := totalCost - others
+		if e.Unit > 0 {
+			e.Price = outputCost / float64(e.Unit)
 		}
+
+		b.record(Step{
+			Cost:       i,
+			Stage:      StageAllocation,
+			Branch:     step.Branch,
+			Key:        costKey(i, "cost", e.Type),
+			Element:    e.Type.Label(),
+			Formula:    "(月初仕掛品原価 + 当月投入原価) - 完成品以外の原価",
+			Expression: "(" + formatNumber(c.FirstCost) + " + " + formatNumber(c.InputCost) + ") - " + formatNumber(shownOthers),
+			Value:      totalCost - shownOthers,
+		})
 	}
+}
 
-	// 正常仕損の扱い
-	for i := 0; i < cCount; i++ {
-		total := b.Costs[i].GetTotalNDBurden()
+// runNormalLoss is i番目のCostのj番目の正常仕損(減損)費を負担する要素に配分する
+// 度外視法は換算量, 非度外視法は数量の割合で按分する
+// 先入先出法では完成品のうち月初仕掛品の分は負担しない
+func (b *Box) runNormalLoss(i int, j int) {
+	c := &b.Costs[i]
+	loss := c.Elements[j]
+	lossCost := loss.Cost()
+
+	var branch string
+	if c.DMethod == Neglecting {
+		branch = "度外視法"
+	} else {
+		branch = "非度外視法"
+	}
 
-		for j := 0; j < len(b.Costs[i].Elements); j++ {
-			percentage := float64(b.Costs[i].Elements[j].NDBurden) / float64(total)
-			b.Costs[i].Elements[j].AddCost(b.Costs[i].GetNormalDefectCost() * percentage)
+	firstUnit := 0
+	if k := Index(First, c.Elements); k >= 0 {
+		if c.DMethod == Neglecting {
+			firstUnit = c.Elements[k].Unit
+		} else {
+			firstUnit = b.Master[k].Unit
 		}
 	}
 
-	// 月末仕掛品原価の計算
-	b.EOTMTotalCost = b.CalculationEOFMCost()
+	// 負担する要素と負担量を決める
+	bothBear := false
+	for k := 0; k < len(c.Elements); k++ {
+		e := &c.Elements[k]
+		e.NDBurden = 0
 
-	// 完成品原価の計算
-	b.ProductTotalCost = b.CalculationProductCost()
+		if !IsBearer(e.Type) {
+			continue
+		}
+		if e.Type != Output && !e.IsBear(loss.Progress) {
+			continue
+		}
 
-	// 完成品単位原価の計算
-	b.ProductAvgCost = b.CalculationProductAvgCost()
+		if c.DMethod == Neglecting {
+			e.NDBurden = e.Unit
+		} else {
+			e.NDBurden = b.Master[k].Unit
+		}
+
+		if e.Type == Output && c.CMethod == FIFO {
+			e.NDBurden -= firstUnit
+		}
+		if e.NDBurden < 0 {
+			e.NDBurden = 0
+		}
+		if e.Type == Last {
+			bothBear = true
+		}
+	}
+
+	if bothBear {
+		branch += "・両者負担"
+	} else {
+		branch += "・完成品のみ負担"
+	}
+
+	// 度外視法で正常仕損を除いて単価を計算したなら, 計算過程に正常仕損費は現れない
+	neglected := b.neglectedLoss(i) >= 0
+	if neglected {
+		b.record(Step{
+			Cost:    i,
+			Stage:   StageNormalLoss,
+			Branch:  branch,
+			Key:     costKey(i, "cost", loss.Type),
+			Element: loss.Type.Label(),
+			Formula: "正常仕損を除いた換算量で単価を計算したので配分しない",
+			Value:   0,
+		})
+	} else {
+		b.record(Step{
+			Cost:       i,
+			Stage:      StageNormalLoss,
+			Branch:     branch,
+			Key:        costKey(i, "cost", loss.Type),
+			Element:    loss.Type.Label(),
+			Formula:    "単価 × 換算量",
+			Expression: formatNumber(loss.Price) + " × " + formatNumber(float64(loss.Unit)),
+			Value:      lossCost,
+		})
+	}
+
+	total := c.GetTotalNDBurden()
+
+	// 負担する要素がなければ配分しない
+	if total == 0 {
+		return
+	}
+
+	for k := 0; k < len(c.Elements); k++ {
+		e := &c.Elements[k]
+		if e.NDBurden == 0 {
+			continue
+		}
+
+		share := lossCost * float64(e.NDBurden) / float64(total)
+		if e.Unit > 0 {
+			e.AddCost(share)
+		}
+
+		if neglected {
+			continue
+		}
+
+		b.record(Step{
+			Cost:    i,
+			Stage:   StageNormalLoss,
+			Branch:  branch,
+			Key:     costKey(i, "burden", e.Type),
+			Element: e.Type.Label(),
+			Formula: loss.Type.Label() + "費 × 負担量 ÷ 負担量合計",
+			Expression: formatNumber(lossCost) + " × " + formatNumber(float64(e.NDBurden)) +
+				" ÷ " + formatNumber(float64(total)),
+			Value: share,
+		})
+	}
+}
+
+// neglectedLoss is i番目のCostで度外視法により無視する正常仕損(減損)の換算量を返す
+// 正常仕損が1つだけで, 完成品以外に負担しうる要素がすべて発生点を通過していれば,
+// 教科書どおり正常仕損を除いた換算量で単価を計算したのと同じ結果になる
+// そうでなければ-1を返す
+func (b Box) neglectedLoss(i int) int {
+	c := b.Costs[i]
+	if c.DMethod != Neglecting {
+		return -1
+	}
+	if GetCountWithElementType(c.Elements, []ElementType{NormalDefect, NormalImpairment}) != 1 {
+		return -1
+	}
+
+	var loss Element
+	for _, e := range c.Elements {
+		if IsNormalLoss(e.Type) {
+			loss = e
+		}
+	}
+
+	for _, e := range c.Elements {
+		if IsBearer(e.Type) && e.Type != Output && e.Unit > 0 && !e.IsBear(loss.Progress) {
+			return -1
+		}
+	}
+
+	return loss.Unit
+}
+
+// joinCost is 各Costのtypeの原価を足し算の式にする
+func (b Box) joinCost(t ElementType) string {
+	var costs []string
+
+	for _, c := range b.Costs {
+		for _, e := range c.Elements {
+			if e.Type == t {
+				costs = append(costs, formatNumber(e.Cost()))
+			}
+		}
+	}
+
+	return strings.Join(costs, " + ")
+}
+
+// record is 計算過程を記録する
+// 原価要素の名前はBoxから補う
+func (b *Box) record(step Step) {
+	if step.Cost >= 0 {
+		step.Name = b.CostName(step.Cost)
+	}
+	b.Trace = append(b.Trace, step)
+}
+
+// IsNormalLoss is 正常仕損または正常減損か判定
+func IsNormalLoss(t ElementType) bool {
+	return t == NormalDefect || t == NormalImpairment
+}
+
+// IsBearer is 正常仕損費を負担しうる要素か判定
+func IsBearer(t ElementType) bool {
+	return t == Output || t == Last || t == AbnormalDefect || t == AbnormalImpairment
 }
 
 // Index is elementsの中からsearchで指定したElementTypeに一致する
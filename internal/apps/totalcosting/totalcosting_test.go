@@ -1,6 +1,7 @@
 package totalcosting
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -501,6 +502,10 @@ func TestCalculationProductAvgCost(t *testing.T) {
 	actual := box.CalculationProductAvgCost()
 	expected := 1300.0
 	assert.Equal(t, expected, actual)
+
+	// 完成品がなければ0で割らない
+	box.Master[0].Unit = 0
+	assert.Equal(t, 0.0, box.CalculationProductAvgCost())
 }
 
 func TestRun(t *testing.T) {
@@ -557,3 +562,193 @@ func TestRun(t *testing.T) {
 	expected2 := 72
 	assert.Equal(t, expected2, actual2)
 }
+
+func TestRunWithoutBearer(t *testing.T) {
+	// 先入先出法で完成品がすべて月初仕掛品から成り, 月末仕掛品が発生点に達していないので誰も負担しない
+	box := Box{
+		Master: []Element{
+			{Type: First, Unit: 100, Progress: 0.8},
+			{Type: Input, Unit: 150},
+			{Type: Output, Unit: 100},
+			{Type: Last, Unit: 40, Progress: 0.2},
+			{Type: NormalDefect, Unit: 10, Progress: 0.5},
+		},
+		Costs: []Cost{
+			{InputOnAvg: true, CMethod: FIFO, DMethod: NonNeglecting, FirstCost: 40000, InputCost: 26400},
+		},
+	}
+
+	box.Run()
+
+	assert.Equal(t, 0, box.Costs[0].GetTotalNDBurden())
+	for _, v := range []float64{box.ProductTotalCost, box.ProductAvgCost, box.EOTMTotalCost} {
+		assert.False(t, math.IsNaN(v) || math.IsInf(v, 0), "%v", v)
+	}
+	assert.Equal(t, 6400.0, box.EOTMTotalCost)
+}
+
+func TestCalulateConversionUnitRound(t *testing.T) {
+	testCases := []struct {
+		Unit     int
+		Progress float64
+		Result   int
+	}{
+		// 切り捨てると99になる
+		{333, 0.3, 100},
+		{245, 0.5, 123},
+		{240, 0.3, 72},
+		{101, 0.2, 20},
+	}
+
+	for _, testCase := range testCases {
+		var c Cost
+		c.CalulateConversionUnit([]Element{
+			{Type: Input},
+			{Type: Last, Unit: testCase.Unit, Progress: testCase.Progress},
+		})
+
+		result := c.Elements[1].Unit
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d", testCase, result)
+		}
+	}
+}
+
+func TestCalulateInputUnitTiming(t *testing.T) {
+	master := []Element{
+		{Type: First, Unit: 300, Progress: 0.6},
+		{Type: Input, Unit: 1200},
+		{Type: Output, Unit: 1200},
+		{Type: NormalDefect, Unit: 60, Progress: 0.4},
+		{Type: Last, Unit: 240, Progress: 0.3},
+	}
+
+	material := Cost{InputTiming: 0.5}
+	material.CalulateInputUnit(master)
+
+	// 投入点(0.5)に達していない正常仕損と月末仕掛品にはまだ投入されていない
+	// 当月投入 = 完成品 - 月初仕掛品
+	expected := []int{300, 900, 1200, 0, 0}
+	for i, e := range material.Elements {
+		assert.Equal(t, expected[i], e.Unit)
+	}
+}
+
+func TestRunNormalLossAllocation(t *testing.T) {
+	testCases := []struct {
+		Master    []Element
+		CMethod   CalculationMethod
+		DMethod   DefectiveProductMethod
+		FirstCost float64
+		InputCost float64
+		Output    float64
+		Last      float64
+		Abnormal  float64
+	}{
+		// 非度外視法・平均法・両者負担: 正常仕損費20,000を数量1,800:200で按分
+		{
+			[]Element{
+				{Type: First, Unit: 400, Progress: 0.5},
+				{Type: Input, Unit: 1700},
+				{Type: Output, Unit: 1800},
+				{Type: NormalDefect, Unit: 100, Progress: 0.4},
+				{Type: Last, Unit: 200, Progress: 0.6},
+			},
+			AVG, NonNeglecting, 60000, 920000,
+			900000 + 18000, 60000 + 2000, 0,
+		},
+		// 非度外視法・先入先出法・両者負担: 完成品のうち月初仕掛品400は負担しない
+		{
+			[]Element{
+				{Type: First, Unit: 400, Progress: 0.5},
+				{Type: Input, Unit: 1700},
+				{Type: Output, Unit: 1800},
+				{Type: NormalDefect, Unit: 100, Progress: 0.4},
+				{Type: Last, Unit: 200, Progress: 0.6},
+			},
+			FIFO, NonNeglecting, 60000, 880000,
+			860000 + 17500, 60000 + 2500, 0,
+		},
+		// 非度外視法・平均法・完成品のみ負担: 月末仕掛品(0.6)は発生点(0.8)に達していない
+		{
+			[]Element{
+				{Type: First, Unit: 400, Progress: 0.5},
+				{Type: Input, Unit: 1700},
+				{Type: Output, Unit: 1800},
+				{Type: NormalDefect, Unit: 100, Progress: 0.8},
+				{Type: Last, Unit: 200, Progress: 0.6},
+			},
+			AVG, NonNeglecting, 60000, 940000,
+			900000 + 40000, 60000, 0,
+		},
+		// 非度外視法・平均法: 発生点を通過した異常仕損も負担する
+		{
+			[]Element{
+				{Type: First, Unit: 400, Progress: 0.5},
+				{Type: Input, Unit: 1700},
+				{Type: Output, Unit: 1600},
+				{Type: NormalDefect, Unit: 100, Progress: 0.4},
+				{Type: AbnormalDefect, Unit: 200, Progress: 0.8},
+				{Type: Last, Unit: 200, Progress: 0.6},
+			},
+			AVG, NonNeglecting, 60000, 900000,
+			800000 + 16000, 60000 + 2000, 80000 + 2000,
+		},
+		// 度外視法・先入先出法・両者負担: 880,000 ÷ (1,760 - 40) × 120
+		{
+			[]Element{
+				{Type: First, Unit: 400, Progress: 0.5},
+				{Type: Input, Unit: 1700},
+				{Type: Output, Unit: 1800},
+				{Type: NormalDefect, Unit: 100, Progress: 0.4},
+				{Type: Last, Unit: 200, Progress: 0.6},
+			},
+			FIFO, Neglecting, 60000, 880000,
+			940000 - 880000.0/1720*120, 880000.0 / 1720 * 120, 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		box := Box{
+			Master: testCase.Master,
+			Costs: []Cost{
+				{InputOnAvg: true, CMethod: testCase.CMethod, DMethod: testCase.DMethod, FirstCost: testCase.FirstCost, InputCost: testCase.InputCost},
+			},
+		}
+		box.Run()
+
+		c := box.Costs[0]
+		actual := []float64{
+			c.Elements[Index(Output, c.Elements)].Cost(),
+			c.Elements[Index(Last, c.Elements)].Cost(),
+			0,
+		}
+		if k := Index(AbnormalDefect, c.Elements); k >= 0 {
+			actual[2] = c.Elements[k].Cost()
+		}
+
+		expected := []float64{testCase.Output, testCase.Last, testCase.Abnormal}
+		if !assert.InDeltaSlice(t, expected, actual, 1e-6) {
+			t.Errorf("Invalid result. testCase:%#v, actual:%v", testCase, actual)
+		}
+	}
+}
+
+func TestCostName(t *testing.T) {
+	box := Box{Costs: []Cost{{Name: "直接材料費"}, {InputOnAvg: true}}}
+
+	testCases := []struct {
+		Index  int
+		Result string
+	}{
+		{0, "直接材料費"},
+		{1, "原価要素2"},
+	}
+
+	for _, testCase := range testCases {
+		result := box.CostName(testCase.Index)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package totalcostingtest
+
+import "github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+
+// Box is 月初仕掛品, 当月投入, 完成品, 月末仕掛品だけの問題
+// 直接材料は始点投入, 加工費は平均的に発生する
+// 加工費には名前を付けないので既定の名前で表示される
+func Box(c totalcosting.CalculationMethod, d totalcosting.DefectiveProductMethod) totalcosting.Box {
+	return totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.First, Unit: 300, Progress: 0.6},
+			{Type: totalcosting.Input, Unit: 1380},
+			{Type: totalcosting.Output, Unit: 1440},
+			{Type: totalcosting.Last, Unit: 240, Progress: 0.3},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "直接材料費", CMethod: c, DMethod: d, FirstCost: 206400, InputCost: 717600},
+			{InputOnAvg: true, CMethod: c, DMethod: d, FirstCost: 161640, InputCost: 972360},
+		},
+	}
+}
+
+// DefectBox is 工程の途中(0.4)で正常仕損が発生する問題
+// 月末仕掛品(0.6)は発生点を通過しているので, 完成品と月末仕掛品の両者が負担する
+func DefectBox(c totalcosting.CalculationMethod, d totalcosting.DefectiveProductMethod) totalcosting.Box {
+	return totalcosting.Box{
+		Master: []totalcosting.Element{
+			{Type: totalcosting.First, Unit: 400, Progress: 0.5},
+			{Type: totalcosting.Input, Unit: 2000},
+			{Type: totalcosting.Output, Unit: 1800},
+			{Type: totalcosting.NormalDefect, Unit: 100, Progress: 0.4},
+			{Type: totalcosting.Last, Unit: 500, Progress: 0.6},
+		},
+		Costs: []totalcosting.Cost{
+			{Name: "直接材料費", CMethod: c, DMethod: d, FirstCost: 80000, InputCost: 380000},
+			{InputOnAvg: true, CMethod: c, DMethod: d, FirstCost: 60000, InputCost: 954000},
+		},
+	}
+}
+
+// Solved is 計算済みのBoxを返す
+func Solved(box totalcosting.Box) totalcosting.Box {
+	box.Run()
+
+	return box
+}
@@ -0,0 +1,162 @@
+package totalcosting
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 計算過程の段階
+const (
+	StageUnit       = "完成品換算量"
+	StagePrice      = "単価"
+	StageAllocation = "原価の配分"
+	StageNormalLoss = "正常仕損費の負担"
+	StageTotal      = "集計"
+)
+
+// Step is 計算過程の1ステップ
+type Step struct {
+	Cost       int     `json:"cost" yaml:"cost"`                           // Costsの添字, Box全体の集計は-1
+	Name       string  `json:"name,omitempty" yaml:"name,omitempty"`       // 原価要素の名前
+	Stage      string  `json:"stage" yaml:"stage"`                         // 段階
+	Branch     string  `json:"branch,omitempty" yaml:"branch,omitempty"`   // 選んだ計算方法
+	Element    string  `json:"element,omitempty" yaml:"element,omitempty"` // 対象の要素の区分
+	Key        string  `json:"key" yaml:"key"`                             // 学習者の解答と照合するためのキー
+	Formula    string  `json:"formula" yaml:"formula"`                     // 計算式
+	Expression string  `json:"expression" yaml:"expression"`               // 数値を当てはめた式
+	Value      float64 `json:"value" yaml:"value"`                         // 計算結果
+}
+
+// String is ステップを1行のテキストで返す
+func (s Step) String() string {
+	var sb strings.Builder
+
+	if s.Cost >= 0 {
+		fmt.Fprintf(&sb, "[%s] ", s.Name)
+	}
+	sb.WriteString(s.Stage)
+	if s.Branch != "" {
+		fmt.Fprintf(&sb, "(%s)", s.Branch)
+	}
+	if s.Element != "" {
+		fmt.Fprintf(&sb, " %s", s.Element)
+	}
+	fmt.Fprintf(&sb, " %s: ", s.Formula)
+	if s.Expression != "" {
+		fmt.Fprintf(&sb, "%s = ", s.Expression)
+	}
+	sb.WriteString(formatNumber(s.Value))
+
+	return sb.String()
+}
+
+// Trace is Box.Runの計算過程
+type Trace []Step
+
+// Text is 計算過程を1ステップ1行のテキストで返す
+func (t Trace) Text() string {
+	var sb strings.Builder
+
+	for i, s := range t {
+		fmt.Fprintf(&sb, "%3d. %s\n", i+1, s)
+	}
+
+	return sb.String()
+}
+
+var traceTemplate = template.Must(template.New("trace").Parse(`<table class="trace">
+<thead><tr><th>#</th><th>原価要素</th><th>段階</th><th>計算方法</th><th>要素</th><th>計算式</th><th>数値</th><th>結果</th></tr></thead>
+<tbody>
+{{- range $i, $s := .}}
+<tr><td>{{$s.Number}}</td><td>{{$s.Cost}}</td><td>{{$s.Stage}}</td><td>{{$s.Branch}}</td><td>{{$s.Element}}</td><td>{{$s.Formula}}</td><td>{{$s.Expression}}</td><td>{{$s.Value}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+`))
+
+// HTML is 計算過程をHTMLの表で返す
+func (t Trace) HTML() (string, error) {
+	type row struct {
+		Number     int
+		Cost       string
+		Stage      string
+		Branch     string
+		Element    string
+		Formula    string
+		Expression string
+		Value      string
+	}
+
+	rows := make([]row, len(t))
+	for i, s := range t {
+		rows[i] = row{i + 1, s.Name, s.Stage, s.Branch, s.Element, s.Formula, s.Expression, formatNumber(s.Value)}
+	}
+
+	var buf bytes.Buffer
+	if err := traceTemplate.Execute(&buf, rows); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// StepResult is 学習者の解答とステップの照合結果
+type StepResult struct {
+	Step     Step    `json:"step"`
+	Answer   float64 `json:"answer"`
+	Answered bool    `json:"answered"`
+	Correct  bool    `json:"correct"`
+}
+
+// Compare is 学習者の計算過程(キーと数値)をステップごとに照合する
+// toleranceは許容する誤差の絶対値
+func (t Trace) Compare(working map[string]float64, tolerance float64) []StepResult {
+	results := make([]StepResult, len(t))
+
+	for i, s := range t {
+		results[i].Step = s
+
+		answer, ok := working[s.Key]
+		if !ok {
+			continue
+		}
+
+		results[i].Answer = answer
+		results[i].Answered = true
+		results[i].Correct = math.Abs(answer-s.Value) <= tolerance
+	}
+
+	return results
+}
+
+// Find is keyのステップを返す
+func (t Trace) Find(key string) (Step, bool) {
+	for _, s := range t {
+		if s.Key == key {
+			return s, true
+		}
+	}
+
+	return Step{}, false
+}
+
+// costKey is 計算過程のキーを返す
+// tが負ならElementTypeを付けない
+func costKey(i int, name string, t ElementType) string {
+	key := fmt.Sprintf("costs[%d].%s", i, name)
+	if t < 0 {
+		return key
+	}
+
+	return key + "." + t.String()
+}
+
+// formatNumber is 計算過程に表示する数値を返す
+// 浮動小数点の誤差が見えないように小数点以下6桁で丸める
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
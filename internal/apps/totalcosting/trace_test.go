@@ -0,0 +1,189 @@
+package totalcosting
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDefectBox(d DefectiveProductMethod, c CalculationMethod) Box {
+	return Box{
+		Master: []Element{
+			{Type: First, Unit: 400, Progress: 0.5},
+			{Type: Input, Unit: 2000},
+			{Type: Output, Unit: 1800},
+			{Type: NormalDefect, Unit: 100, Progress: 0.4},
+			{Type: Last, Unit: 500, Progress: 0.6},
+		},
+		Costs: []Cost{
+			{CMethod: c, DMethod: d, FirstCost: 80000, InputCost: 380000},
+			{InputOnAvg: true, CMethod: c, DMethod: d, FirstCost: 60000, InputCost: 954000},
+		},
+	}
+}
+
+func TestRunNormalDefect(t *testing.T) {
+	testCases := []struct {
+		DMethod      DefectiveProductMethod
+		CMethod      CalculationMethod
+		MaterialLast float64
+	}{
+		// 度外視法・両者負担: 460,000 ÷ (2,400 - 100) × 500
+		{Neglecting, AVG, 100000.0},
+		// 度外視法・両者負担: 380,000 ÷ (2,000 - 100) × 500
+		{Neglecting, FIFO, 100000.0},
+	}
+
+	for _, testCase := range testCases {
+		box := newDefectBox(testCase.DMethod, testCase.CMethod)
+		box.Run()
+
+		actual := box.Costs[0].GetLastCost()
+		assert.InDelta(t, testCase.MaterialLast, actual, 1e-6)
+		assert.InDelta(t, 460000.0+1014000.0, box.ProductTotalCost+box.EOTMTotalCost, 1e-6)
+	}
+}
+
+func TestRunOutputOnlyBears(t *testing.T) {
+	box := newDefectBox(NonNeglecting, AVG)
+	box.Master[3].Progress = 1.0
+	box.Run()
+
+	// 月末仕掛品は正常仕損の発生点を通過していないので負担しない
+	assert.Equal(t, 0, box.Costs[0].Elements[4].NDBurden)
+	assert.InDelta(t, 460000.0/2400.0*500.0, box.Costs[0].GetLastCost(), 1e-6)
+
+	step, ok := box.Trace.Find("costs[0].cost.NormalDefect")
+	assert.True(t, ok)
+	assert.Equal(t, "非度外視法・完成品のみ負担", step.Branch)
+}
+
+func TestTrace(t *testing.T) {
+	box := newDefectBox(Neglecting, AVG)
+	box.Run()
+
+	step, ok := box.Trace.Find("costs[1].units.Last")
+	assert.True(t, ok)
+	assert.Equal(t, "500 × 0.6", step.Expression)
+	assert.Equal(t, 300.0, step.Value)
+
+	step, ok = box.Trace.Find("costs[0].price")
+	assert.True(t, ok)
+	assert.Equal(t, "平均法", step.Branch)
+
+	last := box.Trace[len(box.Trace)-1]
+	assert.Equal(t, "product_avg_cost", last.Key)
+	assert.Equal(t, box.ProductAvgCost, last.Value)
+
+	// Runを繰り返しても計算過程は重複しない
+	count := len(box.Trace)
+	box.Run()
+	assert.Equal(t, count, len(box.Trace))
+}
+
+func TestTraceNeglecting(t *testing.T) {
+	testCases := []struct {
+		CMethod CalculationMethod
+		Input   float64
+		Price   float64
+	}{
+		// (400 + 1,900) で割る
+		{AVG, 1900, 200},
+		// 380,000 ÷ 1,900
+		{FIFO, 1900, 200},
+	}
+
+	for _, testCase := range testCases {
+		box := newDefectBox(Neglecting, testCase.CMethod)
+		box.Run()
+
+		expected := map[string]float64{
+			"costs[0].units.Input": testCase.Input,
+			"costs[0].price":       testCase.Price,
+			"costs[0].cost.Last":   100000,
+			"costs[0].cost.Output": 460000 - 100000,
+		}
+		for key, value := range expected {
+			step, ok := box.Trace.Find(key)
+			if !ok || math.Abs(step.Value-value) > 1e-6 {
+				t.Errorf("Invalid result. testCase:%#v, key:%s, actual:%#v", testCase, key, step)
+			}
+		}
+
+		// 正常仕損費を計算しないので負担額も記録しない
+		_, ok := box.Trace.Find("costs[0].burden.Last")
+		assert.False(t, ok)
+		step, ok := box.Trace.Find("costs[0].cost.NormalDefect")
+		assert.True(t, ok)
+		assert.Equal(t, "度外視法・両者負担", step.Branch)
+		assert.Equal(t, 0.0, step.Value)
+	}
+
+	// 完成品のみ負担なら正常仕損を含めた換算量で単価を計算し, 正常仕損費を完成品に負担させる
+	box := newDefectBox(Neglecting, AVG)
+	box.Master[3].Progress = 1.0
+	box.Run()
+
+	step, ok := box.Trace.Find("costs[0].price")
+	assert.True(t, ok)
+	assert.InDelta(t, 460000.0/2400, step.Value, 1e-6)
+	step, ok = box.Trace.Find("costs[0].burden.Output")
+	assert.True(t, ok)
+	assert.Equal(t, "完成品", step.Element)
+	assert.Contains(t, step.String(), "正常仕損費の負担(度外視法・完成品のみ負担) 完成品 ")
+}
+
+func TestTraceRender(t *testing.T) {
+	box := newDefectBox(Neglecting, FIFO)
+	box.Run()
+
+	// 原価要素はCostNameと同じく1から数え, 換算量の各行にはどの要素かを付ける
+	text := box.Trace.Text()
+	assert.True(t, strings.HasPrefix(text, "  1. [原価要素1] 完成品換算量(定点投入(投入点0)) 月初仕掛品 投入点を通過しているので数量: 400 = 400\n"))
+	assert.Contains(t, text, "  4. [原価要素1] 完成品換算量(定点投入(投入点0)) 月末仕掛品 投入点を通過しているので数量: 500 = 500\n")
+	assert.Contains(t, text, "  7. [原価要素1] 原価の配分(先入先出法) 月末仕掛品 単価 × 換算量: 200 × 500 = 100000\n")
+	assert.Contains(t, text, " 19. 集計 各原価要素の月末仕掛品原価の合計: ")
+
+	html, err := box.Trace.HTML()
+	assert.NoError(t, err)
+	assert.Equal(t, len(box.Trace), strings.Count(html, "<tr><td>"))
+	assert.Contains(t, html, "<tr><td>1</td><td>原価要素1</td><td>完成品換算量</td><td>定点投入(投入点0)</td><td>月初仕掛品</td>")
+
+	data, err := json.Marshal(box.Trace)
+	assert.NoError(t, err)
+
+	var decoded Trace
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, box.Trace, decoded)
+}
+
+func TestTraceCompare(t *testing.T) {
+	box := newDefectBox(Neglecting, AVG)
+	box.Run()
+
+	working := map[string]float64{
+		"costs[1].units.Last": 300,
+		"costs[0].price":      200,
+	}
+	results := box.Trace.Compare(working, 0.5)
+
+	answered := 0
+	for _, r := range results {
+		if !r.Answered {
+			continue
+		}
+		answered++
+
+		switch r.Step.Key {
+		case "costs[1].units.Last":
+			assert.True(t, r.Correct)
+		case "costs[0].price":
+			// 度外視法・両者負担なので単価は460,000 ÷ (400 + 1,900)
+			assert.True(t, r.Correct)
+		}
+	}
+	assert.Equal(t, 2, answered)
+}
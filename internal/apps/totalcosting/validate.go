@@ -0,0 +1,112 @@
+package totalcosting
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is 入力項目1つの検証エラー
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is Boxの検証エラーの一覧
+type ValidationError []FieldError
+
+// Error is 検証エラーをまとめた文字列を返す
+func (v ValidationError) Error() string {
+	messages := make([]string, len(v))
+
+	for i, e := range v {
+		messages[i] = e.Field + ": " + e.Message
+	}
+
+	return "totalcosting: invalid box: " + strings.Join(messages, "; ")
+}
+
+// Validate is Boxが計算できる問題か確認する
+// 問題があればValidationErrorを返す
+func (b Box) Validate() error {
+	var errs ValidationError
+
+	add := func(field string, format string, args ...interface{}) {
+		errs = append(errs, FieldError{field, fmt.Sprintf(format, args...)})
+	}
+
+	if len(b.Master) == 0 {
+		add("master", "at least one element is required")
+	}
+
+	counts := make(map[ElementType]int)
+	left, right := 0, 0
+
+	for i, e := range b.Master {
+		field := fmt.Sprintf("master[%d]", i)
+
+		if e.Type < First || e.Type > AbnormalImpairment {
+			add(field+".type", "unknown element type %d", int(e.Type))
+			continue
+		}
+		counts[e.Type]++
+
+		// 完成品がなければ完成品単位原価を計算できない
+		switch {
+		case e.Type == Output && e.Unit <= 0:
+			add(field+".unit", "must be positive")
+		case e.Unit < 0:
+			add(field+".unit", "must not be negative")
+		}
+		if e.Progress < 0 || e.Progress > 1 {
+			add(field+".progress", "must be between 0 and 1")
+		}
+
+		if e.IsLeftElement() {
+			left += e.Unit
+		} else {
+			right += e.Unit
+		}
+	}
+
+	for _, t := range []ElementType{First, Input, Output, Last} {
+		if counts[t] > 1 {
+			add("master", "%s appears %d times", t, counts[t])
+		}
+	}
+	if len(b.Master) > 0 && counts[Output] == 0 {
+		add("master", "Output is required")
+	}
+	if left != right {
+		add("master", "left side units %d do not match right side units %d", left, right)
+	}
+
+	if len(b.Costs) == 0 {
+		add("costs", "at least one cost is required")
+	}
+
+	for i, c := range b.Costs {
+		field := fmt.Sprintf("costs[%d]", i)
+
+		if c.InputTiming < 0 || c.InputTiming > 1 {
+			add(field+".input_timing", "must be between 0 and 1")
+		}
+		if c.CMethod != FIFO && c.CMethod != AVG {
+			add(field+".calculation_method", "unknown calculation method %d", int(c.CMethod))
+		}
+		if c.DMethod != Neglecting && c.DMethod != NonNeglecting {
+			add(field+".defective_product_method", "unknown defective product method %d", int(c.DMethod))
+		}
+		if c.FirstCost < 0 {
+			add(field+".first_cost", "must not be negative")
+		}
+		if c.InputCost < 0 {
+			add(field+".input_cost", "must not be negative")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package totalcosting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, newTestBox().Validate())
+	assert.NoError(t, newDefectBox(Neglecting, FIFO).Validate())
+}
+
+func TestValidateError(t *testing.T) {
+	testCases := []struct {
+		Modify func(b *Box)
+		Field  string
+	}{
+		{func(b *Box) { b.Master = nil }, "master"},
+		{func(b *Box) { b.Master[0].Progress = 1.5 }, "master[0].progress"},
+		{func(b *Box) { b.Master[1].Unit = -1 }, "master[1].unit"},
+		{func(b *Box) { b.Master[2].Unit = 1000 }, "master"},
+		{func(b *Box) { b.Master[2].Unit = 0 }, "master[2].unit"},
+		{func(b *Box) { b.Master[2].Unit = -1 }, "master[2].unit"},
+		{func(b *Box) { b.Master[3].Type = ElementType(42) }, "master[3].type"},
+		{func(b *Box) { b.Costs = nil }, "costs"},
+		{func(b *Box) { b.Costs[0].InputTiming = -0.1 }, "costs[0].input_timing"},
+		{func(b *Box) { b.Costs[1].CMethod = CalculationMethod(5) }, "costs[1].calculation_method"},
+		{func(b *Box) { b.Costs[1].InputCost = -1 }, "costs[1].input_cost"},
+	}
+
+	for _, testCase := range testCases {
+		box := newTestBox()
+		testCase.Modify(&box)
+
+		err := box.Validate()
+		errs, ok := err.(ValidationError)
+		if !ok || errs[0].Field != testCase.Field {
+			t.Errorf("Invalid result. field:%s, actual:%v", testCase.Field, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package totalcosting
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DecodeBoxYAML is YAMLからBoxを読み込む
+// フィールド名と列挙値はJSON形式と同じ
+// 未知のフィールドがあればエラー
+func DecodeBoxYAML(r io.Reader) (Box, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Box{}, fmt.Errorf("totalcosting: read yaml: %w", err)
+	}
+
+	var box Box
+	if err := yaml.UnmarshalStrict(data, &box); err != nil {
+		return Box{}, fmt.Errorf("totalcosting: decode yaml: %w", err)
+	}
+
+	return box, nil
+}
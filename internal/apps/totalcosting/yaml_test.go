@@ -0,0 +1,50 @@
+package totalcosting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBoxYAML(t *testing.T) {
+	input := `
+master:
+  - {type: 月初仕掛品, unit: 300, progress: 0.6}
+  - {type: Input, unit: 1380}
+  - {type: 完成品, unit: 1440}
+  - {type: Last, unit: 240, progress: 0.3}
+costs:
+  - input_on_avg: false
+    input_timing: 0
+    calculation_method: 平均法
+    defective_product_method: NonNeglecting
+    first_cost: 206400
+    input_cost: 717600
+  - input_on_avg: true
+    calculation_method: AVG
+    defective_product_method: 非度外視法
+    first_cost: 161640
+    input_cost: 972360
+`
+
+	box, err := DecodeBoxYAML(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, newTestBox(), box)
+}
+
+func TestDecodeBoxYAMLError(t *testing.T) {
+	testCases := []string{
+		"master:\n  - {type: Unknown, unit: 1}\n",
+		"master:\n  - {type: 2, unit: 1}\n",
+		"master: []\nextra: true\n",
+		"master: [",
+	}
+
+	for _, testCase := range testCases {
+		_, err := DecodeBoxYAML(strings.NewReader(testCase))
+		if err == nil {
+			t.Errorf("Invalid result. testCase:%q, expected error", testCase)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package xlsx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// SummarySheet is 集計シートの名前
+const SummarySheet = "集計"
+
+// Box図シートの配置
+// 左側(月初仕掛品, 当月投入)はA〜D列, 右側はF〜L列
+const (
+	firstRow = 7 // 要素を書き始める行
+
+	leftType       = 1 // 区分
+	leftUnit       = 2 // 数量
+	leftConversion = 3 // 換算量
+	leftCost       = 4 // 原価
+
+	rightType       = 6  // 区分
+	rightUnit       = 7  // 数量
+	rightConversion = 8  // 換算量
+	rightBurden     = 9  // 負担量
+	rightAllocation = 10 // 配分額
+	rightNormalLoss = 11 // 正常仕損費
+	rightCost       = 12 // 原価
+)
+
+// boxSheet is Box図シートで集計シートから参照するセル
+type boxSheet struct {
+	name   string
+	output string // 完成品原価
+	last   string // 月末仕掛品原価, なければ空
+}
+
+// Export is Run済みのBoxからブックを作る
+// 集計シートと原価要素ごとのBox図シートを作り, 計算は数式で表す
+func Export(box totalcosting.Box) *Workbook {
+	var wb Workbook
+
+	summary := wb.AddSheet(SummarySheet)
+
+	sheets := make([]boxSheet, len(box.Costs))
+	for i, c := range box.Costs {
+		s := wb.AddSheet(box.CostName(i))
+		sheets[i] = writeCost(s, box.Master, c)
+	}
+
+	writeSummary(summary, box, sheets)
+
+	return &wb
+}
+
+// Write is Run済みのBoxをxlsx形式で書き出す
+func Write(w io.Writer, box totalcosting.Box) error {
+	return Export(box).Write(w)
+}
+
+// WriteFile is Run済みのBoxをxlsxファイルに書き出す
+func WriteFile(name string, box totalcosting.Box) error {
+	return Export(box).WriteFile(name)
+}
+
+// writeCost is 原価要素のBox図をシートに書く
+func writeCost(s *Sheet, master []totalcosting.Element, c totalcosting.Cost) boxSheet {
+	timing := "平均的投入"
+	if !c.InputOnAvg {
+		timing = fmt.Sprintf("定点投入(投入点%g)", c.InputTiming)
+	}
+
+	s.Set(1, 1, header("原価要素"))
+	s.Set(2, 1, Text(c.Name))
+	s.Set(1, 2, header("投入"))
+	s.Set(2, 2, Text(timing))
+	s.Set(1, 3, header("月末仕掛品"))
+	s.Set(2, 3, Text(c.CMethod.Label()))
+	s.Set(1, 4, header("正常仕損"))
+	s.Set(2, 4, Text(c.DMethod.Label()))
+
+	for i, h := range []string{"区分", "数量", "換算量", "原価"} {
+		s.Set(leftType+i, firstRow-1, header(h))
+	}
+	for i, h := range []string{"区分", "数量", "換算量", "負担量", "配分額", "正常仕損費", "原価"} {
+		s.Set(rightType+i, firstRow-1, header(h))
+	}
+
+	// 左右に振り分けて行を決める
+	var left, right []int
+	for j, e := range c.Elements {
+		if e.IsLeftElement() {
+			left = append(left, j)
+		} else {
+			right = append(right, j)
+		}
+	}
+
+	rows := len(left)
+	if len(right) > rows {
+		rows = len(right)
+	}
+	totalRow := firstRow + rows
+	priceRow := totalRow + 2
+
+	total := c.FirstCost + c.InputCost
+	price := unitPrice(c)
+
+	// 左側
+	inputRow := 0
+	for k, j := range left {
+		e := c.Elements[j]
+		row := firstRow + k
+
+		amount := c.InputCost
+		if e.Type == totalcosting.First {
+			amount = c.FirstCost
+		} else {
+			inputRow = row
+		}
+
+		s.Set(leftType, row, Text(e.Type.Label()))
+		s.Set(leftUnit, row, Number(float64(unitOf(master, j)), Normal))
+		s.Set(leftConversion, row, Number(float64(e.Unit), Normal))
+		s.Set(leftCost, row, Number(amount, Amount))
+	}
+
+	leftUnits := 0
+	for _, j := range left {
+		leftUnits += c.Elements[j].Unit
+	}
+	s.Set(leftType, totalRow, header("合計"))
+	s.Set(leftConversion, totalRow, Formula(sum(leftConversion, firstRow, totalRow-1), float64(leftUnits), Normal))
+	s.Set(leftCost, totalRow, Formula(sum(leftCost, firstRow, totalRow-1), total, Amount))
+
+	// 単価
+	priceFormula := fmt.Sprintf("IF(%[1]s=0,0,%[2]s/%[1]s)", CellName(leftConversion, totalRow), CellName(leftCost, totalRow))
+	if c.CMethod == totalcosting.FIFO && inputRow > 0 {
+		priceFormula = fmt.Sprintf("IF(%[1]s=0,0,%[2]s/%[1]s)", CellName(leftConversion, inputRow), CellName(leftCost, inputRow))
+	}
+	s.Set(leftType, priceRow, header("単価"))
+	s.Set(leftCost, priceRow, Formula(priceFormula, price, Price))
+	priceRef := "$" + ColumnName(leftCost) + "$" + fmt.Sprint(priceRow)
+
+	// 右側
+	// 完成品の配分額は差額, 正常仕損費が1つなら負担額も数式にする
+	var losses, others []int
+	for k, j := range right {
+		t := c.Elements[j].Type
+		if totalcosting.IsNormalLoss(t) {
+			losses = append(losses, firstRow+k)
+		}
+		if t != totalcosting.Output {
+			others = append(others, firstRow+k)
+		}
+	}
+
+	othersCost := 0.0
+	for _, j := range right {
+		if c.Elements[j].Type != totalcosting.Output {
+			othersCost += price * float64(c.Elements[j].Unit)
+		}
+	}
+
+	distributed := c.GetTotalNDBurden() > 0
+	sheet := boxSheet{name: s.Name}
+	lastRow := firstRow + len(right) - 1
+
+	for k, j := range right {
+		e := c.Elements[j]
+		row := firstRow + k
+
+		s.Set(rightType, row, Text(e.Type.Label()))
+		s.Set(rightUnit, row, Number(float64(unitOf(master, j)), Normal))
+		s.Set(rightConversion, row, Number(float64(e.Unit), Normal))
+		s.Set(rightBurden, row, Number(float64(e.NDBurden), Normal))
+
+		// 配分額
+		var allocation float64
+		if e.Type == totalcosting.Output {
+			allocation = total - othersCost
+			s.Set(rightAllocation, row, Formula(CellName(leftCost, totalRow)+minus(rightAllocation, others), allocation, Amount))
+		} else {
+			allocation = price * float64(e.Unit)
+			s.Set(rightAllocation, row, Formula(priceRef+"*"+CellName(rightConversion, row), allocation, Amount))
+		}
+
+		// 正常仕損費
+		// 正常仕損は負担先に振り替えるので負の値にする
+		var loss float64
+		switch {
+		case totalcosting.IsNormalLoss(e.Type):
+			if distributed {
+				loss = -allocation
+				s.Set(rightNormalLoss, row, Formula("-"+CellName(rightAllocation, row), loss, Amount))
+			} else {
+				s.Set(rightNormalLoss, row, Number(0, Amount))
+			}
+		case len(losses) == 1 && e.NDBurden > 0 && e.Unit > 0:
+			loss = e.Cost() - allocation
+			s.Set(rightNormalLoss, row, Formula(fmt.Sprintf("%s*%s/%s",
+				CellName(rightAllocation, losses[0]), CellName(rightBurden, row), sum(rightBurden, firstRow, lastRow)), loss, Amount))
+		default:
+			loss = e.Cost() - allocation
+			s.Set(rightNormalLoss, row, Number(round(loss), Amount))
+		}
+
+		s.Set(rightCost, row, Formula(CellName(rightAllocation, row)+"+"+CellName(rightNormalLoss, row), allocation+loss, Amount))
+
+		switch e.Type {
+		case totalcosting.Output:
+			sheet.output = CellName(rightCost, row)
+		case totalcosting.Last:
+			sheet.last = CellName(rightCost, row)
+		}
+	}
+
+	rightTotal := 0.0
+	for _, j := range right {
+		e := c.Elements[j]
+		if !totalcosting.IsNormalLoss(e.Type) || !distributed {
+			rightTotal += e.Cost()
+		}
+	}
+	s.Set(rightType, totalRow, header("合計"))
+	s.Set(rightCost, totalRow, Formula(sum(rightCost, firstRow, totalRow-1), rightTotal, Amount))
+
+	return sheet
+}
+
+// writeSummary is 完成品原価, 完成品単位原価, 月末仕掛品原価を集計シートに書く
+func writeSummary(s *Sheet, box totalcosting.Box, sheets []boxSheet) {
+	for i, h := range []string{"原価要素", "完成品原価", "月末仕掛品原価"} {
+		s.Set(1+i, 1, header(h))
+	}
+
+	for i, c := range box.Costs {
+		row := 2 + i
+		sheet := sheets[i]
+
+		s.Set(1, row, Text(sheet.name))
+		s.Set(2, row, reference(sheet.name, sheet.output, costOf(c, totalcosting.Output)))
+		s.Set(3, row, reference(sheet.name, sheet.last, costOf(c, totalcosting.Last)))
+	}
+
+	totalRow := 2 + len(box.Costs)
+	s.Set(1, totalRow, header("合計"))
+	s.Set(2, totalRow, Formula(sum(2, 2, totalRow-1), box.ProductTotalCost, Amount))
+	s.Set(3, totalRow, Formula(sum(3, 2, totalRow-1), box.EOTMTotalCost, Amount))
+
+	outputUnit := 0
+	if i := totalcosting.Index(totalcosting.Output, box.Master); i >= 0 {
+		outputUnit = box.Master[i].Unit
+	}
+
+	row := totalRow + 2
+	s.Set(1, row, header("完成品原価"))
+	s.Set(2, row, Formula(CellName(2, totalRow), box.ProductTotalCost, Amount))
+	s.Set(1, row+1, header("完成品数量"))
+	s.Set(2, row+1, Number(float64(outputUnit), Normal))
+	s.Set(1, row+2, header("完成品単位原価"))
+	s.Set(2, row+2, Formula(fmt.Sprintf("IF(%[1]s=0,0,%[2]s/%[1]s)", CellName(2, row+1), CellName(2, row)), box.ProductAvgCost, Price))
+	s.Set(1, row+3, header("月末仕掛品原価"))
+	s.Set(2, row+3, Formula(CellName(2, totalRow), box.EOTMTotalCost, Amount))
+}
+
+// unitPrice is Box.Runと同じ方法で単価を返す
+func unitPrice(c totalcosting.Cost) float64 {
+	var price float64
+	if c.CMethod == totalcosting.FIFO {
+		price = c.GetPriceFIFO()
+	} else {
+		price = c.GetPriceAVG()
+	}
+
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0.0
+	}
+
+	return price
+}
+
+// unitOf is j番目の要素の数量を返す
+func unitOf(master []totalcosting.Element, j int) int {
+	if j < len(master) {
+		return master[j].Unit
+	}
+
+	return 0
+}
+
+// costOf is typeの要素の原価を返す
+func costOf(c totalcosting.Cost, t totalcosting.ElementType) float64 {
+	if j := totalcosting.Index(t, c.Elements); j >= 0 {
+		return c.Elements[j].Cost()
+	}
+
+	return 0
+}
+
+// reference is 別シートのセルを参照するセルを返す
+// セルがなければ0
+func reference(sheet string, ref string, v float64) Cell {
+	if ref == "" {
+		return Number(0, Amount)
+	}
+
+	return Formula(QuoteSheet(sheet)+"!"+ref, v, Amount)
+}
+
+// header is 見出しのセルを返す
+func header(s string) Cell {
+	c := Text(s)
+	c.Style = Header
+
+	return c
+}
+
+// sum is col列のfrom行からto行までの合計の数式を返す
+func sum(col int, from int, to int) string {
+	if to < from {
+		return "0"
+	}
+
+	return fmt.Sprintf("SUM(%s:%s)", CellName(col, from), CellName(col, to))
+}
+
+// minus is col列のrows行を引く数式を返す
+func minus(col int, rows []int) string {
+	if len(rows) == 0 {
+		return ""
+	}
+
+	refs := make([]string, len(rows))
+	for i, row := range rows {
+		refs[i] = CellName(col, row)
+	}
+
+	return "-(" + strings.Join(refs, "+") + ")"
+}
+
+// round is 浮動小数点の誤差を丸める
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
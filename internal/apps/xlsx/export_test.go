@@ -0,0 +1,75 @@
+package xlsx
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting/totalcostingtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDefectBox is 正常仕損のある問題
+// 加工費だけ先入先出法で評価する
+func newDefectBox() totalcosting.Box {
+	box := totalcostingtest.DefectBox(totalcosting.AVG, totalcosting.NonNeglecting)
+	box.Costs[1].CMethod = totalcosting.FIFO
+
+	return box
+}
+
+func TestExport(t *testing.T) {
+	box := newDefectBox()
+	box.Run()
+
+	wb := Export(box)
+	assert.Equal(t, 3, len(wb.Sheets))
+	assert.Equal(t, []string{"集計", "直接材料費", "原価要素2"}, []string{wb.Sheets[0].Name, wb.Sheets[1].Name, wb.Sheets[2].Name})
+
+	// 集計シート
+	summary := wb.Sheets[0]
+	c, _ := summary.Get(2, 2)
+	assert.Equal(t, "'直接材料費'!L7", c.Formula)
+	assert.Equal(t, box.Costs[0].Elements[2].Cost(), c.Number)
+	c, _ = summary.Get(2, 4)
+	assert.Equal(t, "SUM(B2:B3)", c.Formula)
+	assert.Equal(t, box.ProductTotalCost, c.Number)
+	c, _ = summary.Get(2, 8)
+	assert.Equal(t, "IF(B7=0,0,B6/B7)", c.Formula)
+	assert.Equal(t, box.ProductAvgCost, c.Number)
+	c, _ = summary.Get(2, 9)
+	assert.Equal(t, box.EOTMTotalCost, c.Number)
+
+	// 平均法: 単価は合計から
+	material := wb.Sheets[1]
+	c, _ = material.Get(leftCost, 12)
+	assert.Equal(t, "IF(C10=0,0,D10/C10)", c.Formula)
+	assert.Equal(t, 460000.0/2400, c.Number)
+
+	// 完成品は差額, 正常仕損費は負担量で按分
+	c, _ = material.Get(rightAllocation, 7)
+	assert.Equal(t, "D10-(J8+J9)", c.Formula)
+	c, _ = material.Get(rightNormalLoss, 7)
+	assert.Equal(t, "J8*I7/SUM(I7:I9)", c.Formula)
+	c, _ = material.Get(rightNormalLoss, 8)
+	assert.Equal(t, "-J8", c.Formula)
+	assert.InDelta(t, -460000.0/2400*100, c.Number, 1e-6)
+
+	for _, row := range []int{7, 9} {
+		c, _ = material.Get(rightCost, row)
+		assert.Equal(t, box.Costs[0].Elements[row-5].Cost(), c.Number)
+	}
+	c, _ = material.Get(rightCost, 10)
+	assert.InDelta(t, 460000.0, c.Number, 1e-6)
+
+	// 先入先出法: 単価は当月投入から
+	conversion := wb.Sheets[2]
+	c, _ = conversion.Get(leftCost, 12)
+	assert.Equal(t, "IF(C8=0,0,D8/C8)", c.Formula)
+	c, _ = conversion.Get(rightCost, 7)
+	assert.InDelta(t, box.Costs[1].Elements[2].Cost(), c.Number, 1e-6)
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, box))
+	assert.True(t, buf.Len() > 0)
+}
@@ -0,0 +1,333 @@
+package xlsx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/csvimport"
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+)
+
+// PhysicalSheet is テンプレートの物量シートの名前
+const PhysicalSheet = "物量"
+
+// templateMapping is テンプレートの見出し
+// 物量シートは1行目が見出しの表, 原価要素シートはA列が項目名でB列が値
+var templateMapping = csvimport.Mapping{
+	Type:        "区分",
+	Unit:        "数量",
+	Progress:    "加工進捗度",
+	InputTiming: "投入点",
+	CMethod:     "月末仕掛品の評価",
+	DMethod:     "正常仕損の処理",
+	FirstCost:   "月初仕掛品原価",
+	InputCost:   "当月投入原価",
+}
+
+// costItems is 原価要素シートの項目(csvimportの項目名, 見出し, 必須か)
+var costItems = []struct {
+	field    string
+	label    string
+	required bool
+}{
+	{"input_timing", templateMapping.InputTiming, true},
+	{"calculation_method", templateMapping.CMethod, true},
+	{"defective_product_method", templateMapping.DMethod, false},
+	{"first_cost", templateMapping.FirstCost, false},
+	{"input_cost", templateMapping.InputCost, true},
+}
+
+// CellError is シートとセルを示すエラー
+type CellError struct {
+	Sheet string // シート名, ブック全体なら空
+	Cell  string // セル参照, シート全体なら空
+	Err   error
+}
+
+// Error is エラーの位置と内容を返す
+func (e CellError) Error() string {
+	switch {
+	case e.Sheet == "":
+		return e.Err.Error()
+	case e.Cell == "":
+		return e.Sheet + ": " + e.Err.Error()
+	}
+
+	return e.Sheet + "!" + e.Cell + ": " + e.Err.Error()
+}
+
+// Unwrap is 元のエラーを返す
+func (e CellError) Unwrap() error {
+	return e.Err
+}
+
+// ImportError is 読み込みと検証で見つかったエラーの一覧
+type ImportError []CellError
+
+// Error is エラーを1行ずつまとめた文字列を返す
+func (e ImportError) Error() string {
+	messages := make([]string, len(e))
+
+	for i, c := range e {
+		messages[i] = c.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// ImportFile is テンプレートのxlsxファイルからBoxを作る
+func ImportFile(name string) (totalcosting.Box, error) {
+	wb, err := ReadFile(name)
+	if err != nil {
+		return totalcosting.Box{}, err
+	}
+
+	return ImportWorkbook(wb)
+}
+
+// Import is テンプレートのxlsxからBoxを作る
+func Import(r io.ReaderAt, size int64) (totalcosting.Box, error) {
+	wb, err := Read(r, size)
+	if err != nil {
+		return totalcosting.Box{}, err
+	}
+
+	return ImportWorkbook(wb)
+}
+
+// ImportWorkbook is テンプレートのブックからBoxを作って検証する
+// 物量シートの後ろのシートを1枚ずつ原価要素として読み, シート名を原価要素の名前にする
+// 集計シートと#で始まるシートは読まない
+// エラーがあればシートとセルを示すImportErrorを返す
+func ImportWorkbook(wb *Workbook) (totalcosting.Box, error) {
+	var box totalcosting.Box
+	var errs ImportError
+
+	im := csvimport.Importer{Mapping: templateMapping}
+
+	physical := wb.Sheet(PhysicalSheet)
+	if physical == nil {
+		return box, ImportError{{Err: fmt.Errorf("sheet %q is missing", PhysicalSheet)}}
+	}
+
+	// 物量シート
+	records := physical.Records()
+	master, rowErrs := im.MasterFromRecords(records)
+	box.Master = master
+	for _, e := range rowErrs {
+		errs = append(errs, cellError(physical.Name, e, func(row int, col int) string { return CellName(col+1, row) }))
+	}
+	masterRows := dataRows(records)
+
+	// 原価要素シート
+	var costSheets []*Sheet
+	var costRows []map[string]int
+	for _, s := range wb.Sheets {
+		if s == physical || strings.EqualFold(s.Name, SummarySheet) || strings.HasPrefix(s.Name, "#") {
+			continue
+		}
+
+		header, values, rows := costRecords(s)
+		costs, rowErrs := im.CostsFromRecords([][]string{header, values})
+		for _, e := range rowErrs {
+			errs = append(errs, cellError(s.Name, e, func(row int, col int) string { return CellName(2, rows[col]) }))
+		}
+
+		c := totalcosting.Cost{Name: s.Name}
+		if len(costs) > 0 {
+			c = costs[0]
+			c.Name = s.Name
+		}
+		box.Costs = append(box.Costs, c)
+		costSheets = append(costSheets, s)
+
+		// 必須項目の空欄はcsvimportがエラーにするが, 値がすべて空欄だと行ごと読み飛ばされるのでここで確かめる
+		fields := make(map[string]int)
+		for k, h := range header {
+			for _, item := range costItems {
+				if item.label != h {
+					continue
+				}
+				fields[item.field] = rows[k]
+				if len(costs) == 0 && item.required {
+					errs = append(errs, CellError{Sheet: s.Name, Cell: CellName(2, rows[k]), Err: errors.New("required value is blank")})
+				}
+			}
+		}
+		costRows = append(costRows, fields)
+	}
+
+	if len(errs) > 0 {
+		return box, errs
+	}
+
+	// 検証エラーをセルに対応付ける
+	if err := box.Validate(); err != nil {
+		verrs, ok := err.(totalcosting.ValidationError)
+		if !ok {
+			return box, err
+		}
+
+		for _, v := range verrs {
+			e := CellError{Err: errors.New(v.Message)}
+
+			index, field := parseField(v.Field)
+			switch {
+			case strings.HasPrefix(v.Field, "master"):
+				e.Sheet = physical.Name
+				if index >= 0 && index < len(masterRows) {
+					e.Cell = masterCell(records[0], masterRows[index], field)
+				}
+			case strings.HasPrefix(v.Field, "costs") && index >= 0 && index < len(costSheets):
+				e.Sheet = costSheets[index].Name
+				if row, ok := costRows[index][field]; ok {
+					e.Cell = CellName(2, row)
+				}
+			}
+
+			errs = append(errs, e)
+		}
+
+		return box, errs
+	}
+
+	return box, nil
+}
+
+// Template is Boxをテンプレートの形式でブックにする
+func Template(box totalcosting.Box) *Workbook {
+	var wb Workbook
+
+	physical := wb.AddSheet(PhysicalSheet)
+	for i, h := range []string{templateMapping.Type, templateMapping.Unit, templateMapping.Progress} {
+		physical.Set(1+i, 1, header(h))
+	}
+	for i, e := range box.Master {
+		physical.Set(1, 2+i, Text(e.Type.Label()))
+		physical.Set(2, 2+i, Number(float64(e.Unit), Normal))
+		if e.Progress != 0 {
+			physical.Set(3, 2+i, Number(e.Progress, Normal))
+		}
+	}
+
+	for i, c := range box.Costs {
+		s := wb.AddSheet(box.CostName(i))
+
+		timing := Number(c.InputTiming, Normal)
+		if c.InputOnAvg {
+			timing = Text("平均的投入")
+		}
+
+		values := []Cell{
+			timing,
+			Text(c.CMethod.Label()),
+			Text(c.DMethod.Label()),
+			Number(c.FirstCost, Amount),
+			Number(c.InputCost, Amount),
+		}
+		for k, item := range costItems {
+			s.Set(1, 1+k, header(item.label))
+			s.Set(2, 1+k, values[k])
+		}
+	}
+
+	return &wb
+}
+
+// WriteTemplate is Boxをテンプレートの形式で書き出す
+func WriteTemplate(w io.Writer, box totalcosting.Box) error {
+	return Template(box).Write(w)
+}
+
+// costRecords is 原価要素シートのA列を見出し, B列を値として1行ずつに並べる
+// rowsは見出しごとのシートの行番号
+func costRecords(s *Sheet) ([]string, []string, []int) {
+	var header, values []string
+	var rows []int
+
+	for row, record := range s.Records() {
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		value := ""
+		if len(record) > 1 {
+			value = record[1]
+		}
+
+		header = append(header, strings.TrimSpace(record[0]))
+		values = append(values, value)
+		rows = append(rows, row+1)
+	}
+
+	return header, values, rows
+}
+
+// dataRows is 空行を除いたデータ行のシートの行番号を返す
+// MasterFromRecordsのElementの順に対応する
+func dataRows(records [][]string) []int {
+	var rows []int
+
+	if len(records) == 0 {
+		return rows
+	}
+
+	for i, record := range records[1:] {
+		for _, v := range record {
+			if strings.TrimSpace(v) != "" {
+				rows = append(rows, i+2)
+				break
+			}
+		}
+	}
+
+	return rows
+}
+
+// masterCell is 物量シートでfieldの列のセル参照を返す
+// 列がなければ行の先頭のセル
+func masterCell(header []string, row int, field string) string {
+	labels := map[string]string{
+		"type":     templateMapping.Type,
+		"unit":     templateMapping.Unit,
+		"progress": templateMapping.Progress,
+	}
+
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), labels[field]) {
+			return CellName(i+1, row)
+		}
+	}
+
+	return CellName(1, row)
+}
+
+// fieldPattern is 検証エラーの項目名(master[0].unitなど)
+var fieldPattern = regexp.MustCompile(`^\w+\[(\d+)\](?:\.(\w+))?$`)
+
+// parseField is 検証エラーの項目名から添字と項目を返す
+// 添字がなければ-1
+func parseField(field string) (int, string) {
+	m := fieldPattern.FindStringSubmatch(field)
+	if m == nil {
+		return -1, ""
+	}
+
+	index, _ := strconv.Atoi(m[1])
+
+	return index, m[2]
+}
+
+// cellError is csvimportのエラーをシートとセルのエラーにする
+// 列がなければシート全体のエラー
+func cellError(sheet string, e csvimport.RowError, ref func(row int, col int) string) CellError {
+	if e.Column < 0 {
+		return CellError{Sheet: sheet, Err: fmt.Errorf("%s: %v", e.Field, e.Err)}
+	}
+
+	return CellError{Sheet: sheet, Cell: ref(e.Row, e.Column), Err: e.Err}
+}
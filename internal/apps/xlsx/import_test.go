@@ -0,0 +1,101 @@
+package xlsx
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/KeisukeIwabuchi/Costing/internal/apps/totalcosting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImport(t *testing.T) {
+	box := newDefectBox()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteTemplate(&buf, box))
+
+	imported, err := Import(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	assert.Equal(t, box.Master, imported.Master)
+	box.Costs[1].Name = "原価要素2"
+	assert.Equal(t, box.Costs, imported.Costs)
+
+	// ファイルから読み込み, 集計シートは読まない
+	wb := Template(box)
+	wb.AddSheet(SummarySheet).Set(1, 1, Text("メモ"))
+	wb.AddSheet("#メモ").Set(1, 1, Text("メモ"))
+
+	name := filepath.Join(t.TempDir(), "problem.xlsx")
+	assert.NoError(t, wb.WriteFile(name))
+
+	imported, err = ImportFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(imported.Costs))
+
+	imported.Run()
+	expected := newDefectBox()
+	expected.Run()
+	assert.Equal(t, expected.ProductTotalCost, imported.ProductTotalCost)
+}
+
+func TestImportError(t *testing.T) {
+	box := newDefectBox()
+
+	wb := Template(box)
+	physical := wb.Sheet(PhysicalSheet)
+	physical.Set(2, 3, Text("2000個"))
+	physical.Set(1, 4, Text("不明"))
+	physical.Set(2, 5, Text(""))
+	material := wb.Sheet("直接材料費")
+	material.Set(2, 2, Text("LIFO"))
+	material.Set(2, 5, Text(""))
+	wb.Sheets[2].Set(1, 4, Text("月初原価"))
+
+	_, err := ImportWorkbook(wb)
+	errs, ok := err.(ImportError)
+	assert.True(t, ok)
+
+	expected := []string{
+		`物量!B3: parsing "2000個": invalid syntax`,
+		`物量!A4: unknown element type "不明"`,
+		`物量!B5: required value is blank`,
+		`直接材料費!B2: unknown calculation method "LIFO"`,
+		`直接材料費!B5: required value is blank`,
+	}
+	assert.Equal(t, len(expected), len(errs))
+	for i, e := range errs {
+		assert.Equal(t, expected[i], e.Error())
+	}
+
+	// 値がすべて空欄の原価要素シート
+	wb = Template(box)
+	for row := 1; row <= len(costItems); row++ {
+		wb.Sheets[2].Set(2, row, Text(""))
+	}
+
+	_, err = ImportWorkbook(wb)
+	assert.EqualError(t, err, "原価要素2!B1: required value is blank\n原価要素2!B2: required value is blank\n原価要素2!B5: required value is blank")
+
+	// 検証エラー
+	wb = Template(box)
+	wb.Sheet(PhysicalSheet).Set(2, 6, Number(-500, Normal))
+	wb.Sheets[2].Set(2, 5, Number(-1, Amount))
+
+	_, err = ImportWorkbook(wb)
+	errs, ok = err.(ImportError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{
+		"物量!B6: must not be negative",
+		"物量: left side units 2400 do not match right side units 1400",
+		"原価要素2!B5: must not be negative",
+	}, []string{errs[0].Error(), errs[1].Error(), errs[2].Error()})
+
+	// 物量シートがない
+	_, err = ImportWorkbook(&Workbook{})
+	assert.EqualError(t, err, `sheet "物量" is missing`)
+
+	_, err = ImportWorkbook(Template(totalcosting.Box{Master: box.Master}))
+	assert.Error(t, err)
+}
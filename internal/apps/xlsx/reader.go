@@ -0,0 +1,277 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ReadFile is xlsxファイルを読み込む
+func ReadFile(name string) (*Workbook, error) {
+	z, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+
+	return readZip(&z.Reader)
+}
+
+// Read is xlsxを読み込む
+// 読み込むのはセルの値と数式だけで, 書式は読まない
+func Read(r io.ReaderAt, size int64) (*Workbook, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return readZip(z)
+}
+
+// String is セルの値を文字列で返す
+func (c Cell) String() string {
+	if c.IsText {
+		return c.Text
+	}
+
+	return strconv.FormatFloat(c.Number, 'f', -1, 64)
+}
+
+// Records is シートの値を1行目から行ごとに返す
+// セルのない行は空の行になる
+func (s *Sheet) Records() [][]string {
+	rows := s.rows()
+	if len(rows) == 0 {
+		return nil
+	}
+
+	records := make([][]string, rows[len(rows)-1])
+	for _, row := range rows {
+		columns := s.columns(row)
+		record := make([]string, columns[len(columns)-1])
+		for _, col := range columns {
+			record[col-1] = s.cells[row][col].String()
+		}
+		records[row-1] = record
+	}
+
+	return records
+}
+
+// Excelのシートの最大の行と列(XFD1048576)
+// 読み込むときにこれを超える番地は不正として扱う
+const (
+	maxRow    = 1048576
+	maxColumn = 16384
+)
+
+// ParseCellName is A1形式のセル参照から列と行(どちらも1始まり)を返す
+func ParseCellName(ref string) (int, int, error) {
+	col := 0
+	i := 0
+
+	for ; i < len(ref); i++ {
+		ch := ref[i]
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		if col > maxColumn {
+			return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+
+	row, err := strconv.Atoi(ref[i:])
+	if col == 0 || err != nil || !isValidRow(row) {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+
+	return col, row, nil
+}
+
+// isValidRow is シートに存在しうる行番号か判定
+func isValidRow(row int) bool {
+	return row >= 1 && row <= maxRow
+}
+
+// xmlWorkbook is xl/workbook.xml
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xmlRelationships is xl/_rels/workbook.xml.rels
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xmlText is 書式付きの文字列(リッチテキストは連結する)
+type xmlText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String is 連結した文字列を返す
+func (t xmlText) String() string {
+	s := t.T
+	for _, r := range t.R {
+		s += r.T
+	}
+
+	return s
+}
+
+// xmlSharedStrings is xl/sharedStrings.xml
+type xmlSharedStrings struct {
+	Items []xmlText `xml:"si"`
+}
+
+// xmlWorksheet is xl/worksheets/sheetN.xml
+type xmlWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string  `xml:"r,attr"`
+			T  string  `xml:"t,attr"`
+			F  string  `xml:"f"`
+			V  string  `xml:"v"`
+			Is xmlText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readZip is zipの中のブックを読み込む
+func readZip(z *zip.Reader) (*Workbook, error) {
+	files := make(map[string]*zip.File)
+	for _, f := range z.File {
+		files[f.Name] = f
+	}
+
+	var book xmlWorkbook
+	if err := decodeXML(files, "xl/workbook.xml", &book); err != nil {
+		return nil, err
+	}
+
+	var rels xmlRelationships
+	if err := decodeXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+
+	// 共有文字列はないこともある
+	var shared xmlSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var wb Workbook
+	for _, s := range book.Sheets {
+		var ws xmlWorksheet
+		if err := decodeXML(files, targets[s.ID], &ws); err != nil {
+			return nil, err
+		}
+
+		sheet := &Sheet{Name: s.Name}
+		for i, r := range ws.Rows {
+			row := r.R
+			if row == 0 {
+				row = i + 1
+			}
+			if !isValidRow(row) {
+				return nil, fmt.Errorf("%s: invalid row number %d", s.Name, row)
+			}
+
+			for j, c := range r.Cells {
+				col := j + 1
+				if c.R != "" {
+					var err error
+					if col, row, err = ParseCellName(c.R); err != nil {
+						return nil, fmt.Errorf("%s: %v", s.Name, err)
+					}
+				}
+
+				cell, err := parseCell(c.T, c.V, c.F, c.Is, shared)
+				if err != nil {
+					return nil, fmt.Errorf("%s!%s: %v", s.Name, CellName(col, row), err)
+				}
+				sheet.Set(col, row, cell)
+			}
+		}
+		wb.Sheets = append(wb.Sheets, sheet)
+	}
+
+	return &wb, nil
+}
+
+// parseCell is セルの種類tに応じて値を読む
+func parseCell(t string, v string, f string, is xmlText, shared xmlSharedStrings) (Cell, error) {
+	switch t {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(shared.Items) {
+			return Cell{}, fmt.Errorf("invalid shared string %q", v)
+		}
+		return Text(shared.Items[i].String()), nil
+	case "inlineStr":
+		return Text(is.String()), nil
+	case "str", "e":
+		c := Text(v)
+		c.Formula = f
+		return c, nil
+	case "b":
+		if v == "1" {
+			return Text("TRUE"), nil
+		}
+		return Text("FALSE"), nil
+	}
+
+	if v == "" {
+		return Text(""), nil
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return Cell{}, fmt.Errorf("invalid number %q", v)
+	}
+
+	return Formula(f, n, Normal), nil
+}
+
+// decodeXML is zipの中のnameを読み込む
+func decodeXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s is missing", name)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return xml.NewDecoder(r).Decode(v)
+}
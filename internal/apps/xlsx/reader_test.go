@@ -0,0 +1,114 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCellName(t *testing.T) {
+	testCases := []struct {
+		Ref string
+		Col int
+		Row int
+	}{
+		{"A1", 1, 1},
+		{"L7", 12, 7},
+		{"aa10", 27, 10},
+		{"AAA1", 703, 1},
+	}
+
+	for _, testCase := range testCases {
+		col, row, err := ParseCellName(testCase.Ref)
+		if err != nil || col != testCase.Col || row != testCase.Row {
+			t.Errorf("Invalid result. testCase:%#v, actual:%d %d %v", testCase, col, row, err)
+		}
+	}
+
+	for _, ref := range []string{"", "A", "1", "A0", "A-1", "A1B", "A1048577", "XFE1", "ZZZZZZZZZZZZZZ1"} {
+		_, _, err := ParseCellName(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestRead(t *testing.T) {
+	var wb Workbook
+	s := wb.AddSheet("物量")
+	s.Set(1, 1, Text("区分"))
+	s.Set(3, 1, Number(0.6, Normal))
+	s.Set(2, 3, Formula("B1*2", 2000, Amount))
+
+	var buf bytes.Buffer
+	assert.NoError(t, wb.Write(&buf))
+
+	read, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(read.Sheets))
+	assert.Equal(t, "物量", read.Sheets[0].Name)
+
+	assert.Equal(t, [][]string{
+		{"区分", "", "0.6"},
+		nil,
+		{"", "2000"},
+	}, read.Sheets[0].Records())
+
+	c, ok := read.Sheets[0].Get(2, 3)
+	assert.True(t, ok)
+	assert.Equal(t, "B1*2", c.Formula)
+
+	_, err = Read(bytes.NewReader([]byte("not a zip")), 9)
+	assert.Error(t, err)
+}
+
+func TestReadInvalidRow(t *testing.T) {
+	for _, row := range []string{"-1", "1048577", "99999999999"} {
+		var buf bytes.Buffer
+		z := zip.NewWriter(&buf)
+		files := map[string]string{
+			"xl/workbook.xml":            `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="物量" r:id="rId1"/></sheets></workbook>`,
+			"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+			"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="` + row + `"><c t="inlineStr"><is><t>完成品</t></is></c></row></sheetData></worksheet>`,
+		}
+		for name, content := range files {
+			w, err := z.Create(name)
+			assert.NoError(t, err)
+			_, err = w.Write([]byte(content))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, z.Close())
+
+		_, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.Error(t, err, row)
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	shared := xmlSharedStrings{Items: []xmlText{{T: "完成品"}, {R: []struct {
+		T string `xml:"t"`
+	}{{"月末"}, {"仕掛品"}}}}}
+
+	testCases := []struct {
+		T      string
+		V      string
+		Result string
+	}{
+		{"s", "0", "完成品"},
+		{"s", "1", "月末仕掛品"},
+		{"b", "1", "TRUE"},
+		{"str", "平均法", "平均法"},
+		{"", "1380", "1380"},
+		{"n", "0.3", "0.3"},
+	}
+
+	for _, testCase := range testCases {
+		c, err := parseCell(testCase.T, testCase.V, "", xmlText{}, shared)
+		if err != nil || c.String() != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s %v", testCase, c.String(), err)
+		}
+	}
+
+	_, err := parseCell("s", "2", "", xmlText{}, shared)
+	assert.Error(t, err)
+}
@@ -0,0 +1,357 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Style is セルの書式
+type Style int
+
+// セルの書式(標準, 見出し, 金額, 単価)
+const (
+	Normal Style = iota
+	Header
+	Amount
+	Price
+)
+
+// Cell is セルの値
+// Formulaがあれば数式を書き, Numberは計算結果として保存する
+type Cell struct {
+	Text    string  // 文字列
+	Number  float64 // 数値
+	Formula string  // 先頭の=を除いた数式
+	IsText  bool    // 文字列のセルか
+	Style   Style
+}
+
+// Text is 文字列のセルを返す
+func Text(s string) Cell {
+	return Cell{Text: s, IsText: true}
+}
+
+// Number is 数値のセルを返す
+func Number(v float64, style Style) Cell {
+	return Cell{Number: v, Style: style}
+}
+
+// Formula is 数式のセルを返す
+// vは数式の計算結果で, 再計算しないビューアで表示される
+func Formula(f string, v float64, style Style) Cell {
+	return Cell{Formula: f, Number: v, Style: style}
+}
+
+// Sheet is ワークシート
+type Sheet struct {
+	Name  string
+	Width float64 // 列幅, 0なら既定値
+	cells map[int]map[int]Cell
+}
+
+// Set is col列row行(どちらも1始まり)にセルを書く
+func (s *Sheet) Set(col int, row int, c Cell) {
+	if s.cells == nil {
+		s.cells = make(map[int]map[int]Cell)
+	}
+	if s.cells[row] == nil {
+		s.cells[row] = make(map[int]Cell)
+	}
+
+	s.cells[row][col] = c
+}
+
+// Get is col列row行のセルを返す
+func (s *Sheet) Get(col int, row int) (Cell, bool) {
+	c, ok := s.cells[row][col]
+
+	return c, ok
+}
+
+// Workbook is ブック
+type Workbook struct {
+	Sheets []*Sheet
+}
+
+// AddSheet is シートを追加する
+// 使えない文字は取り除き, 31文字に切り詰め, 重複すれば番号を付ける
+func (wb *Workbook) AddSheet(name string) *Sheet {
+	name = SheetName(name)
+
+	unique := name
+	for n := 2; wb.Sheet(unique) != nil; n++ {
+		suffix := fmt.Sprintf("(%d)", n)
+		unique = truncate(name, 31-len([]rune(suffix))) + suffix
+	}
+
+	s := &Sheet{Name: unique}
+	wb.Sheets = append(wb.Sheets, s)
+
+	return s
+}
+
+// Sheet is nameのシートを返す
+// 大文字と小文字は区別せず, なければnil
+func (wb *Workbook) Sheet(name string) *Sheet {
+	for _, s := range wb.Sheets {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Write is ブックをxlsx形式で書き出す
+func (wb *Workbook) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", wb.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", wb.workbook()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for i, s := range wb.Sheets {
+		files = append(files, struct {
+			name string
+			body string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), s.xml()})
+	}
+
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// WriteFile is ブックをxlsxファイルに書き出す
+func (wb *Workbook) WriteFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := wb.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const rootRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles is Styleの順に並べた書式
+// 金額は#,##0(組み込み3), 単価は#,##0.00(組み込み4)
+const styles = xmlHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
+
+// contentTypes is [Content_Types].xmlを返す
+func (wb *Workbook) contentTypes() string {
+	var sb strings.Builder
+
+	sb.WriteString(xmlHeader)
+	sb.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	sb.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	sb.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	sb.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	sb.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.Sheets {
+		fmt.Fprintf(&sb, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	sb.WriteString(`</Types>`)
+
+	return sb.String()
+}
+
+// workbook is xl/workbook.xmlを返す
+// 開いたときに数式を再計算させる
+func (wb *Workbook) workbook() string {
+	var sb strings.Builder
+
+	sb.WriteString(xmlHeader)
+	sb.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range wb.Sheets {
+		fmt.Fprintf(&sb, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.Name), i+1, i+1)
+	}
+	sb.WriteString(`</sheets><calcPr fullCalcOnLoad="1"/></workbook>`)
+
+	return sb.String()
+}
+
+// workbookRels is xl/_rels/workbook.xml.relsを返す
+// シートはrId1から, 書式はその後ろ
+func (wb *Workbook) workbookRels() string {
+	var sb strings.Builder
+
+	sb.WriteString(xmlHeader)
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.Sheets {
+		fmt.Fprintf(&sb, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&sb, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.Sheets)+1)
+	sb.WriteString(`</Relationships>`)
+
+	return sb.String()
+}
+
+// xml is シートのxmlを返す
+// セルは行, 列の順に並べる
+func (s *Sheet) xml() string {
+	var sb strings.Builder
+
+	sb.WriteString(xmlHeader)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	width := s.Width
+	if width == 0 {
+		width = 14
+	}
+	fmt.Fprintf(&sb, `<cols><col min="1" max="16" width="%s" customWidth="1"/></cols>`, strconv.FormatFloat(width, 'f', -1, 64))
+
+	sb.WriteString(`<sheetData>`)
+	for _, row := range s.rows() {
+		fmt.Fprintf(&sb, `<row r="%d">`, row)
+		for _, col := range s.columns(row) {
+			sb.WriteString(s.cells[row][col].xml(CellName(col, row)))
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+
+	return sb.String()
+}
+
+// xml is セルのxmlを返す
+// 文字列は共有文字列を使わずインラインで書く
+func (c Cell) xml(ref string) string {
+	style := ""
+	if c.Style != Normal {
+		style = fmt.Sprintf(` s="%d"`, c.Style)
+	}
+
+	if c.IsText {
+		return fmt.Sprintf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(c.Text))
+	}
+
+	formula := ""
+	if c.Formula != "" {
+		formula = "<f>" + escape(c.Formula) + "</f>"
+	}
+
+	return fmt.Sprintf(`<c r="%s"%s>%s<v>%s</v></c>`, ref, style, formula, strconv.FormatFloat(c.Number, 'f', -1, 64))
+}
+
+// CellName is col列row行(どちらも1始まり)のセル参照(A1形式)を返す
+func CellName(col int, row int) string {
+	return ColumnName(col) + strconv.Itoa(row)
+}
+
+// ColumnName is col列目(1始まり)の列名を返す
+func ColumnName(col int) string {
+	name := ""
+
+	for col > 0 {
+		col--
+		name = string(rune('A'+col%26)) + name
+		col /= 26
+	}
+
+	return name
+}
+
+// SheetName is シート名に使えない文字を取り除き31文字に切り詰める
+func SheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), "'")
+
+	if name == "" {
+		name = "Sheet"
+	}
+
+	return truncate(name, 31)
+}
+
+// QuoteSheet is 数式で参照するためにシート名を引用符で囲む
+func QuoteSheet(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// truncate is sをn文字に切り詰める
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n])
+}
+
+// escape is xmlの特殊文字をエスケープする
+func escape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+
+	return sb.String()
+}
+
+// rows is セルのある行番号を昇順で返す
+func (s *Sheet) rows() []int {
+	var rows []int
+
+	for row := range s.cells {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+
+	return rows
+}
+
+// columns is row行のセルのある列番号を昇順で返す
+func (s *Sheet) columns(row int) []int {
+	var columns []int
+
+	for col := range s.cells[row] {
+		columns = append(columns, col)
+	}
+	sort.Ints(columns)
+
+	return columns
+}
@@ -0,0 +1,75 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCellName(t *testing.T) {
+	testCases := []struct {
+		Col    int
+		Row    int
+		Result string
+	}{
+		{1, 1, "A1"},
+		{12, 7, "L7"},
+		{26, 3, "Z3"},
+		{27, 10, "AA10"},
+		{703, 1, "AAA1"},
+	}
+
+	for _, testCase := range testCases {
+		result := CellName(testCase.Col, testCase.Row)
+		if result != testCase.Result {
+			t.Errorf("Invalid result. testCase:%#v, actual:%s", testCase, result)
+		}
+	}
+}
+
+func TestAddSheet(t *testing.T) {
+	var wb Workbook
+
+	assert.Equal(t, "加工費", wb.AddSheet("加工費").Name)
+	assert.Equal(t, "加工費(2)", wb.AddSheet("加工費").Name)
+	assert.Equal(t, "ab", wb.AddSheet("a/b").Name)
+	assert.Equal(t, "Sheet", wb.AddSheet("[]").Name)
+	assert.Equal(t, 31, len([]rune(wb.AddSheet("0123456789012345678901234567890123456789").Name)))
+	assert.Equal(t, "'a''b'", QuoteSheet("a'b"))
+}
+
+func TestWrite(t *testing.T) {
+	var wb Workbook
+	s := wb.AddSheet("集計")
+	s.Set(1, 1, Text("完成品原価 <A&B>"))
+	s.Set(2, 1, Number(1000, Amount))
+	s.Set(2, 2, Formula("B1*2", 2000, Amount))
+
+	var buf bytes.Buffer
+	assert.NoError(t, wb.Write(&buf))
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		files[f.Name] = string(body)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="集計" sheetId="1" r:id="rId1"/>`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t xml:space="preserve">完成品原価 &lt;A&amp;B&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B1" s="2"><v>1000</v></c>`)
+	assert.Contains(t, sheet, `<row r="2"><c r="B2" s="2"><f>B1*2</f><v>2000</v></c></row>`)
+}
@@ -0,0 +1,9 @@
+package web
+
+import _ "embed" // 画面のファイルを埋め込む
+
+// Index is 問題を入力して解く画面
+// 1つの実行ファイルで動くように埋め込む
+//
+//go:embed index.html
+var Index []byte